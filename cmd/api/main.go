@@ -2,12 +2,17 @@ package main
 
 import (
 	"log"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/udacc/uda-cycling-club/internal/config"
 	"github.com/udacc/uda-cycling-club/internal/database"
 	"github.com/udacc/uda-cycling-club/internal/models"
 	"github.com/udacc/uda-cycling-club/internal/routes"
+	"github.com/udacc/uda-cycling-club/internal/scheduler"
+	"github.com/udacc/uda-cycling-club/internal/services"
+	"github.com/udacc/uda-cycling-club/pkg/payment"
+	"gorm.io/gorm"
 )
 
 func main() {
@@ -26,12 +31,50 @@ func main() {
 		&models.RideType{},
 		&models.Ride{},
 		&models.RideParticipant{},
+		&models.RideStaff{},
+		&models.EligibilityRule{},
+		&models.EligibilityOverride{},
+		&models.DifficultySettings{},
+		&models.RideComment{},
+		&models.CommentMention{},
+		&models.RidePhoto{},
+		&models.RiderPosition{},
+		&models.RideGroup{},
+		&models.RideReport{},
+		&models.RideIncident{},
+		&models.Waiver{},
+		&models.WaiverVersion{},
+		&models.WaiverAcceptance{},
+		&models.RideTypeWaiver{},
+		&models.AuditLog{},
+		&models.RideCompletion{},
+		&models.CreditLedgerEntry{},
+		&models.Badge{},
+		&models.UserBadge{},
+		&models.UserStreak{},
 	)
 	if err != nil {
 		log.Fatalf("Failed to migrate database: %v", err)
 	}
 
+	if err := database.MigrateSearch(db); err != nil {
+		log.Fatalf("Failed to set up ride search: %v", err)
+	}
+
 	seedRideTypes()
+	seedBadges()
+	backfillRideTypePolicies()
+	backfillPublishedAt()
+	backfillDifficulty()
+
+	services.Payments, err = payment.NewProvider(cfg.PaymentProvider)
+	if err != nil {
+		log.Fatalf("Failed to set up payments: %v", err)
+	}
+
+	if cfg.SchedulerEnabled && cfg.SchedulerInterval > 0 {
+		scheduler.New(db, time.Duration(cfg.SchedulerInterval)*time.Second).Start()
+	}
 
 	app := fiber.New(fiber.Config{
 		ErrorHandler: func(c *fiber.Ctx, err error) error {
@@ -56,8 +99,64 @@ func seedRideTypes() {
 	for _, rt := range models.DefaultRideTypes {
 		var existing models.RideType
 		if err := database.DB.Where("id = ?", rt.ID).First(&existing).Error; err != nil {
+			now := time.Now()
+			rt.PolicyConfiguredAt = &now
 			database.DB.Create(&rt)
 			log.Printf("Seeded ride type: %s", rt.Name)
 		}
 	}
 }
+
+// backfillRideTypePolicies gives ride types created before scheduler
+// policies existed their default policy. It runs once per type: afterwards
+// the policy is the admin's to change, including turning scheduling off.
+func backfillRideTypePolicies() {
+	for _, rt := range models.DefaultRideTypes {
+		result := database.DB.Model(&models.RideType{}).
+			Where("id = ? AND policy_configured_at IS NULL", rt.ID).
+			Where("auto_start = ? AND auto_complete = ? AND default_duration_minutes = 0 AND completion_grace_minutes = 0", false, false).
+			Updates(map[string]interface{}{
+				"auto_start":               rt.AutoStart,
+				"auto_complete":            rt.AutoComplete,
+				"default_duration_minutes": rt.DefaultDurationMinutes,
+				"completion_grace_minutes": rt.CompletionGraceMinutes,
+			})
+		if result.RowsAffected > 0 {
+			log.Printf("Set scheduler defaults for ride type: %s", rt.Name)
+		}
+	}
+
+	database.DB.Model(&models.RideType{}).
+		Where("policy_configured_at IS NULL").
+		Update("policy_configured_at", time.Now())
+}
+
+func seedBadges() {
+	for _, badge := range models.DefaultBadges {
+		var existing models.Badge
+		if err := database.DB.Where("slug = ?", badge.Slug).First(&existing).Error; err != nil {
+			database.DB.Create(&badge)
+			log.Printf("Seeded badge: %s", badge.Name)
+		}
+	}
+}
+
+// backfillPublishedAt dates rides published before publish times were
+// recorded, so they still show up in the rides feed
+func backfillPublishedAt() {
+	database.DB.Model(&models.Ride{}).
+		Where("published_at IS NULL AND status != ?", models.RideStatusDraft).
+		Update("published_at", gorm.Expr("created_at"))
+}
+
+// backfillDifficulty scores rides created before difficulty scoring existed
+func backfillDifficulty() {
+	rescored, err := services.RescoreUnscoredRides(database.DB)
+	if err != nil {
+		log.Printf("Failed to rescore ride difficulty: %v", err)
+		return
+	}
+	if rescored > 0 {
+		log.Printf("Rescored difficulty of %d rides", rescored)
+	}
+}
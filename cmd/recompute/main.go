@@ -0,0 +1,47 @@
+// Command recompute rebuilds every member's distance and ride totals from
+// the credit ledger, first reconciling the ledger with completed ride
+// participation, rebuilds every member's riding streaks and awards any
+// badges members have earned but don't hold.
+package main
+
+import (
+	"log"
+	"time"
+
+	"github.com/udacc/uda-cycling-club/internal/config"
+	"github.com/udacc/uda-cycling-club/internal/database"
+	"github.com/udacc/uda-cycling-club/internal/services"
+)
+
+func main() {
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("Failed to load config: %v", err)
+	}
+
+	db, err := database.Connect(cfg)
+	if err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+
+	reconciled, err := services.RecomputeTotals(db)
+	if err != nil {
+		log.Fatalf("Failed to recompute totals: %v", err)
+	}
+
+	log.Printf("Recomputed member totals, reconciled %d users", reconciled)
+
+	users, err := services.RecomputeStreaks(db, time.Now())
+	if err != nil {
+		log.Fatalf("Failed to recompute streaks: %v", err)
+	}
+
+	log.Printf("Recomputed streaks of %d users", users)
+
+	awarded, err := services.BackfillBadges(db)
+	if err != nil {
+		log.Fatalf("Failed to backfill badges: %v", err)
+	}
+
+	log.Printf("Awarded %d badges", awarded)
+}
@@ -1,6 +1,7 @@
 package config
 
 import (
+	"fmt"
 	"os"
 	"strconv"
 
@@ -22,11 +23,28 @@ type Config struct {
 	JWTExpiryHours       int
 	JWTRefreshExpiryDays int
 
-	UploadDir   string
-	MaxFileSize int64
+	UploadDir     string
+	MaxFileSize   int64
+	MaxPhotoSize  int64
+	ThumbnailSize int
 
 	FacebookAppID     string
 	FacebookAppSecret string
+
+	SchedulerEnabled  bool
+	SchedulerInterval int
+
+	CheckInCodeTTL             int
+	CheckInRadiusMeters        int
+	CheckInWindowBeforeMinutes int
+	CheckInWindowAfterMinutes  int
+
+	LiveFeedInterval int
+
+	PaymentProvider string
+
+	SeasonStartMonth int
+	SeasonEndMonth   int
 }
 
 var AppConfig *Config
@@ -37,6 +55,24 @@ func Load() (*Config, error) {
 	jwtExpiryHours, _ := strconv.Atoi(getEnv("JWT_EXPIRY_HOURS", "24"))
 	jwtRefreshExpiryDays, _ := strconv.Atoi(getEnv("JWT_REFRESH_EXPIRY_DAYS", "7"))
 	maxFileSize, _ := strconv.ParseInt(getEnv("MAX_FILE_SIZE", "10485760"), 10, 64)
+	maxPhotoSize, _ := strconv.ParseInt(getEnv("MAX_PHOTO_SIZE", "8388608"), 10, 64)
+	thumbnailSize, _ := strconv.Atoi(getEnv("THUMBNAIL_SIZE", "400"))
+	checkInCodeTTL, _ := strconv.Atoi(getEnv("CHECKIN_CODE_TTL_SECONDS", "120"))
+	checkInRadius, _ := strconv.Atoi(getEnv("CHECKIN_RADIUS_METERS", "200"))
+	checkInWindowBefore, _ := strconv.Atoi(getEnv("CHECKIN_WINDOW_BEFORE_MINUTES", "60"))
+	checkInWindowAfter, _ := strconv.Atoi(getEnv("CHECKIN_WINDOW_AFTER_MINUTES", "60"))
+	liveFeedInterval, _ := strconv.Atoi(getEnv("LIVE_FEED_INTERVAL_SECONDS", "5"))
+	schedulerEnabled, _ := strconv.ParseBool(getEnv("SCHEDULER_ENABLED", "true"))
+	schedulerInterval, _ := strconv.Atoi(getEnv("SCHEDULER_INTERVAL_SECONDS", "60"))
+	seasonStartMonth, _ := strconv.Atoi(getEnv("SEASON_START_MONTH", "4"))
+	seasonEndMonth, _ := strconv.Atoi(getEnv("SEASON_END_MONTH", "10"))
+
+	if seasonStartMonth < 1 || seasonStartMonth > 12 {
+		return nil, fmt.Errorf("SEASON_START_MONTH must be between 1 and 12")
+	}
+	if seasonEndMonth < 1 || seasonEndMonth > 12 {
+		return nil, fmt.Errorf("SEASON_END_MONTH must be between 1 and 12")
+	}
 
 	AppConfig = &Config{
 		Port: getEnv("PORT", "3000"),
@@ -53,11 +89,28 @@ func Load() (*Config, error) {
 		JWTExpiryHours:       jwtExpiryHours,
 		JWTRefreshExpiryDays: jwtRefreshExpiryDays,
 
-		UploadDir:   getEnv("UPLOAD_DIR", "./uploads"),
-		MaxFileSize: maxFileSize,
+		UploadDir:     getEnv("UPLOAD_DIR", "./uploads"),
+		MaxFileSize:   maxFileSize,
+		MaxPhotoSize:  maxPhotoSize,
+		ThumbnailSize: thumbnailSize,
 
 		FacebookAppID:     getEnv("FACEBOOK_APP_ID", ""),
 		FacebookAppSecret: getEnv("FACEBOOK_APP_SECRET", ""),
+
+		SchedulerEnabled:  schedulerEnabled,
+		SchedulerInterval: schedulerInterval,
+
+		CheckInCodeTTL:             checkInCodeTTL,
+		CheckInRadiusMeters:        checkInRadius,
+		CheckInWindowBeforeMinutes: checkInWindowBefore,
+		CheckInWindowAfterMinutes:  checkInWindowAfter,
+
+		LiveFeedInterval: liveFeedInterval,
+
+		PaymentProvider: getEnv("PAYMENT_PROVIDER", "fake"),
+
+		SeasonStartMonth: seasonStartMonth,
+		SeasonEndMonth:   seasonEndMonth,
 	}
 
 	return AppConfig, nil
@@ -0,0 +1,26 @@
+package database
+
+import "gorm.io/gorm"
+
+// MigrateSearch adds the full-text search column and index on rides, which
+// AutoMigrate can't express. The 'simple' configuration lowercases and
+// splits words without language-specific stemming, so Mongolian Cyrillic
+// titles are indexed as well as Latin ones. Titles weigh more than
+// descriptions when ranking.
+func MigrateSearch(db *gorm.DB) error {
+	statements := []string{
+		`ALTER TABLE rides ADD COLUMN IF NOT EXISTS search_vector tsvector
+			GENERATED ALWAYS AS (
+				setweight(to_tsvector('simple', coalesce(title, '')), 'A') ||
+				setweight(to_tsvector('simple', coalesce(description, '')), 'B')
+			) STORED`,
+		`CREATE INDEX IF NOT EXISTS idx_rides_search_vector ON rides USING GIN (search_vector)`,
+	}
+
+	for _, stmt := range statements {
+		if err := db.Exec(stmt).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -40,6 +40,12 @@ type UpdateProfileRequest struct {
 	IsPrivate *bool  `json:"is_private"`
 }
 
+type EmergencyContactRequest struct {
+	Name         string `json:"name"`
+	Phone        string `json:"phone"`
+	Relationship string `json:"relationship"`
+}
+
 type AuthResponse struct {
 	Token        string              `json:"token"`
 	RefreshToken string              `json:"refresh_token"`
@@ -237,6 +243,47 @@ func UpdateMe(c *fiber.Ctx) error {
 	return c.JSON(user.ToResponse(user.IsAdmin))
 }
 
+func GetMyEmergencyContact(c *fiber.Ctx) error {
+	user := middleware.GetCurrentUser(c)
+	return c.JSON(user.EmergencyContact)
+}
+
+func UpdateMyEmergencyContact(c *fiber.Ctx) error {
+	user := middleware.GetCurrentUser(c)
+
+	var req EmergencyContactRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	req.Name = strings.TrimSpace(req.Name)
+	req.Phone = strings.TrimSpace(req.Phone)
+
+	// Both name and phone are needed for the contact to be useful; sending
+	// neither clears it
+	if (req.Name == "") != (req.Phone == "") {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Emergency contact name and phone are both required",
+		})
+	}
+
+	user.EmergencyContact = models.EmergencyContact{
+		Name:         req.Name,
+		Phone:        req.Phone,
+		Relationship: strings.TrimSpace(req.Relationship),
+	}
+
+	if err := database.DB.Save(user).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to update emergency contact",
+		})
+	}
+
+	return c.JSON(user.EmergencyContact)
+}
+
 func ChangePassword(c *fiber.Ctx) error {
 	user := middleware.GetCurrentUser(c)
 
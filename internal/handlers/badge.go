@@ -0,0 +1,182 @@
+package handlers
+
+import (
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/udacc/uda-cycling-club/internal/database"
+	"github.com/udacc/uda-cycling-club/internal/middleware"
+	"github.com/udacc/uda-cycling-club/internal/models"
+)
+
+type BadgeRequest struct {
+	Slug        string               `json:"slug"`
+	Name        string               `json:"name"`
+	Description string               `json:"description"`
+	IconURL     string               `json:"icon_url"`
+	RuleType    models.BadgeRuleType `json:"rule_type"`
+	Threshold   float64              `json:"threshold"`
+	Scope       models.BadgeScope    `json:"scope"`
+	RideTypeID  *uint                `json:"ride_type_id"`
+	IsActive    *bool                `json:"is_active"`
+}
+
+// applyBadgeRequest copies the request onto the badge. It returns a
+// non-empty message when the request is invalid.
+func applyBadgeRequest(badge *models.Badge, req *BadgeRequest) string {
+	req.Slug = strings.TrimSpace(req.Slug)
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Slug == "" || req.Name == "" {
+		return "Slug and name are required"
+	}
+	if !req.RuleType.IsValid() {
+		return "Rule type must be one of single_ride_distance, total_distance, ride_count, passes_climbed, rides_led, ride_types_completed"
+	}
+	if req.Scope == "" {
+		req.Scope = models.BadgeScopeAllTime
+	}
+	if !req.Scope.IsValid() {
+		return "Scope must be one of all_time, season"
+	}
+	if req.Threshold < 0 || (req.Threshold == 0 && req.RuleType != models.BadgeRuleRideTypesCompleted) {
+		return "Threshold must be positive"
+	}
+	if req.RideTypeID != nil {
+		if req.RuleType == models.BadgeRuleRideTypesCompleted {
+			return "A ride types badge can't be limited to one ride type"
+		}
+		var rideType models.RideType
+		if err := database.DB.First(&rideType, *req.RideTypeID).Error; err != nil {
+			return "Invalid ride type"
+		}
+	}
+
+	var existing models.Badge
+	if err := database.DB.Where("slug = ? AND id <> ?", req.Slug, badge.ID).First(&existing).Error; err == nil {
+		return "A badge with this slug already exists"
+	}
+
+	badge.Slug = req.Slug
+	badge.Name = req.Name
+	badge.Description = req.Description
+	badge.IconURL = req.IconURL
+	badge.RuleType = req.RuleType
+	badge.Threshold = req.Threshold
+	badge.Scope = req.Scope
+	badge.RideTypeID = req.RideTypeID
+	if req.IsActive != nil {
+		badge.IsActive = *req.IsActive
+	}
+	return ""
+}
+
+// ListBadges lists the badges members can earn. Admins also see inactive
+// ones.
+func ListBadges(c *fiber.Ctx) error {
+	query := database.DB.Order("id")
+	if !middleware.IsAdmin(c) {
+		query = query.Where("is_active = ?", true)
+	}
+
+	var badges []models.Badge
+	query.Find(&badges)
+
+	return c.JSON(fiber.Map{
+		"badges": badges,
+	})
+}
+
+func CreateBadge(c *fiber.Ctx) error {
+	var req BadgeRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	badge := models.Badge{IsActive: true}
+	if msg := applyBadgeRequest(&badge, &req); msg != "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": msg,
+		})
+	}
+
+	if err := database.DB.Create(&badge).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to create badge",
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(badge)
+}
+
+func UpdateBadge(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid badge ID",
+		})
+	}
+
+	var badge models.Badge
+	if err := database.DB.First(&badge, id).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Badge not found",
+		})
+	}
+
+	var req BadgeRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	if msg := applyBadgeRequest(&badge, &req); msg != "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": msg,
+		})
+	}
+
+	if err := database.DB.Save(&badge).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to update badge",
+		})
+	}
+
+	return c.JSON(badge)
+}
+
+// GetUserBadges lists the badges a user has been awarded, newest first
+func GetUserBadges(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid user ID",
+		})
+	}
+
+	var user models.User
+	if err := database.DB.First(&user, "id = ?", id).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "User not found",
+		})
+	}
+
+	var awarded []models.UserBadge
+	database.DB.
+		Preload("Badge").
+		Where("user_id = ?", id).
+		Order("awarded_at DESC").
+		Find(&awarded)
+
+	responses := make([]models.UserBadgeResponse, len(awarded))
+	for i := range awarded {
+		responses[i] = awarded[i].ToResponse()
+	}
+
+	return c.JSON(fiber.Map{
+		"badges": responses,
+	})
+}
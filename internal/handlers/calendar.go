@@ -0,0 +1,198 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/udacc/uda-cycling-club/internal/database"
+	"github.com/udacc/uda-cycling-club/internal/middleware"
+	"github.com/udacc/uda-cycling-club/internal/models"
+	"github.com/udacc/uda-cycling-club/pkg/ical"
+	"gorm.io/gorm"
+)
+
+const (
+	calendarProductID = "-//UDA Cycling Club//Rides//EN"
+	// Past rides stay in the feeds this long so recent history doesn't vanish
+	// from members' calendars
+	calendarHistory = 90 * 24 * time.Hour
+)
+
+// scheduleChanged reports whether an update touched anything calendar
+// clients show, in which case the event's sequence must be bumped.
+func scheduleChanged(before, after *models.Ride) bool {
+	return before.Title != after.Title ||
+		!equalTimePtr(before.StartTime, after.StartTime) ||
+		!equalIntPtr(before.EstimatedDurationMinutes, after.EstimatedDurationMinutes) ||
+		before.MeetingPointName != after.MeetingPointName ||
+		!equalFloatPtr(before.MeetingPointLat, after.MeetingPointLat) ||
+		!equalFloatPtr(before.MeetingPointLng, after.MeetingPointLng)
+}
+
+func equalTimePtr(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Equal(*b)
+}
+
+func equalIntPtr(a, b *int) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+func equalFloatPtr(a, b *float64) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+func rideEvent(ride *models.Ride) ical.Event {
+	event := ical.Event{
+		UID:          fmt.Sprintf("ride-%s@udacc", ride.ID),
+		Sequence:     ride.CalendarSequence,
+		Created:      ride.CreatedAt,
+		LastModified: ride.UpdatedAt,
+		Start:        *ride.StartTime,
+		End:          ride.EstimatedEnd(),
+		Summary:      ride.Title,
+		Location:     ride.MeetingPointName,
+		Lat:          ride.MeetingPointLat,
+		Lng:          ride.MeetingPointLng,
+		Status:       ical.StatusConfirmed,
+	}
+
+	var description strings.Builder
+	description.WriteString(ride.Description)
+	if ride.DistanceKm > 0 {
+		if description.Len() > 0 {
+			description.WriteString("\n\n")
+		}
+		fmt.Fprintf(&description, "Distance: %.1f km, elevation gain: %.0f m", ride.DistanceKm, ride.ElevationGain)
+	}
+	event.Description = description.String()
+
+	if ride.Status == models.RideStatusCancelled || ride.DeletedAt.Valid {
+		event.Status = ical.StatusCancelled
+	}
+
+	return event
+}
+
+// calendarRides loads the rides for a feed. Deleted rides are included so
+// calendar clients see them as cancelled rather than silently dropping them.
+func calendarRides(query *gorm.DB) []models.Ride {
+	var rides []models.Ride
+	query.
+		Unscoped().
+		Preload("RideType").
+		Where("rides.status != ?", models.RideStatusDraft).
+		Where("rides.start_time IS NOT NULL AND rides.start_time >= ?", time.Now().Add(-calendarHistory)).
+		Order("rides.start_time").
+		Find(&rides)
+	return rides
+}
+
+func sendCalendar(c *fiber.Ctx, name string, rides []models.Ride) error {
+	cal := ical.Calendar{
+		ProductID: calendarProductID,
+		Name:      name,
+		Events:    make([]ical.Event, len(rides)),
+	}
+	for i := range rides {
+		cal.Events[i] = rideEvent(&rides[i])
+	}
+
+	data, err := cal.Bytes()
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to build calendar",
+		})
+	}
+
+	c.Set(fiber.HeaderContentType, "text/calendar; charset=utf-8")
+	return c.Send(data)
+}
+
+// ClubCalendar is the public feed of all club rides
+func ClubCalendar(c *fiber.Ctx) error {
+	query := database.DB.Model(&models.Ride{})
+	if rideTypeID := c.QueryInt("ride_type_id"); rideTypeID > 0 {
+		query = query.Where("rides.ride_type_id = ?", rideTypeID)
+	}
+
+	return sendCalendar(c, "UDA Cycling Club", calendarRides(query))
+}
+
+// UserCalendar is a member's private feed of the rides they registered for,
+// addressed by a secret token so calendar apps can fetch it without logging in
+func UserCalendar(c *fiber.Ctx) error {
+	token := c.Params("token")
+
+	var user models.User
+	if token == "" || database.DB.Where("calendar_token = ?", token).First(&user).Error != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Calendar not found",
+		})
+	}
+
+	query := database.DB.Model(&models.Ride{}).
+		Joins("JOIN ride_participants ON ride_participants.ride_id = rides.id").
+		Where("ride_participants.user_id = ?", user.ID)
+
+	return sendCalendar(c, "UDA Cycling Club - My rides", calendarRides(query))
+}
+
+func newCalendarToken() (string, error) {
+	buf := make([]byte, 24)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+func calendarResponse(token string) fiber.Map {
+	return fiber.Map{
+		"token": token,
+		"url":   "/api/v1/calendar/users/" + token + ".ics",
+	}
+}
+
+// GetMyCalendar returns the current user's personal feed URL, creating the
+// token on first use
+func GetMyCalendar(c *fiber.Ctx) error {
+	user := middleware.GetCurrentUser(c)
+
+	if user.CalendarToken == nil {
+		return RotateMyCalendar(c)
+	}
+
+	return c.JSON(calendarResponse(*user.CalendarToken))
+}
+
+// RotateMyCalendar replaces the personal feed token, breaking the old URL
+func RotateMyCalendar(c *fiber.Ctx) error {
+	user := middleware.GetCurrentUser(c)
+
+	token, err := newCalendarToken()
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to generate calendar token",
+		})
+	}
+
+	if err := database.DB.Model(user).Update("calendar_token", token).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to save calendar token",
+		})
+	}
+
+	return c.JSON(calendarResponse(token))
+}
@@ -0,0 +1,280 @@
+package handlers
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/udacc/uda-cycling-club/internal/config"
+	"github.com/udacc/uda-cycling-club/internal/database"
+	"github.com/udacc/uda-cycling-club/internal/middleware"
+	"github.com/udacc/uda-cycling-club/internal/models"
+	"github.com/udacc/uda-cycling-club/internal/services"
+	"github.com/udacc/uda-cycling-club/pkg/gpx"
+)
+
+type CheckInRequest struct {
+	Code string `json:"code"`
+}
+
+type LocationCheckInRequest struct {
+	Lat            *float64 `json:"lat"`
+	Lng            *float64 `json:"lng"`
+	AccuracyMeters float64  `json:"accuracy_meters"`
+}
+
+// checkInCodeResponse builds the payload the leader's screen renders as a QR code
+func checkInCodeResponse(ride *models.Ride) fiber.Map {
+	code, expiresAt := services.CheckInCode(ride, time.Now())
+	return fiber.Map{
+		"code":       code,
+		"expires_at": expiresAt,
+		"qr_payload": fmt.Sprintf("udacc://check-in?ride_id=%s&code=%s", ride.ID, code),
+	}
+}
+
+// GetCheckInCode returns the current self check-in code of an ongoing ride
+func GetCheckInCode(c *fiber.Ctx) error {
+	user := middleware.GetCurrentUser(c)
+
+	rideID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid ride ID",
+		})
+	}
+
+	var ride models.Ride
+	if err := database.DB.First(&ride, "id = ?", rideID).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Ride not found",
+		})
+	}
+
+	if !isRideStaff(&ride, user) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "Only ride staff can display the check-in code",
+		})
+	}
+
+	if ride.Status != models.RideStatusOngoing {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Check-in codes are only available for ongoing rides",
+		})
+	}
+
+	if ride.CheckInNonce == "" {
+		ride.CheckInNonce = services.NewCheckInNonce()
+		if err := database.DB.Model(&ride).Update("check_in_nonce", ride.CheckInNonce).Error; err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to create check-in code",
+			})
+		}
+	}
+
+	return c.JSON(checkInCodeResponse(&ride))
+}
+
+// RotateCheckInCode invalidates all previously issued check-in codes
+func RotateCheckInCode(c *fiber.Ctx) error {
+	user := middleware.GetCurrentUser(c)
+
+	rideID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid ride ID",
+		})
+	}
+
+	var ride models.Ride
+	if err := database.DB.First(&ride, "id = ?", rideID).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Ride not found",
+		})
+	}
+
+	if !isRideStaff(&ride, user) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "Only ride staff can rotate the check-in code",
+		})
+	}
+
+	if ride.Status != models.RideStatusOngoing {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Check-in codes are only available for ongoing rides",
+		})
+	}
+
+	ride.CheckInNonce = services.NewCheckInNonce()
+	if err := database.DB.Model(&ride).Update("check_in_nonce", ride.CheckInNonce).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to rotate check-in code",
+		})
+	}
+
+	return c.JSON(checkInCodeResponse(&ride))
+}
+
+// CheckIn marks the current user attended using the code shown by the leader
+func CheckIn(c *fiber.Ctx) error {
+	user := middleware.GetCurrentUser(c)
+
+	rideID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid ride ID",
+		})
+	}
+
+	var ride models.Ride
+	if err := database.DB.First(&ride, "id = ?", rideID).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Ride not found",
+		})
+	}
+
+	if ride.Status != models.RideStatusOngoing {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Can only check in to ongoing rides",
+		})
+	}
+
+	var participant models.RideParticipant
+	if err := database.DB.Where("ride_id = ? AND user_id = ?", rideID, user.ID).First(&participant).Error; err != nil {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "Not registered for this ride",
+		})
+	}
+
+	var req CheckInRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	now := time.Now()
+	if !services.VerifyCheckInCode(&ride, req.Code, now) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid or expired check-in code",
+		})
+	}
+
+	if participant.CheckedInAt == nil {
+		participant.Attended = true
+		participant.CheckedInAt = &now
+		participant.CheckInMethod = models.CheckInMethodCode
+
+		if err := database.DB.Save(&participant).Error; err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to check in",
+			})
+		}
+	}
+
+	database.DB.Preload("User").First(&participant, "id = ?", participant.ID)
+
+	return c.JSON(participant.ToResponse(user.IsAdmin))
+}
+
+// LocationCheckIn marks the current user attended when they are at the
+// meeting point around the ride's start time
+func LocationCheckIn(c *fiber.Ctx) error {
+	user := middleware.GetCurrentUser(c)
+
+	rideID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid ride ID",
+		})
+	}
+
+	var ride models.Ride
+	if err := database.DB.First(&ride, "id = ?", rideID).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Ride not found",
+		})
+	}
+
+	if ride.Status != models.RideStatusPublished && ride.Status != models.RideStatusOngoing {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Can only check in to published or ongoing rides",
+		})
+	}
+
+	if ride.StartTime == nil || ride.MeetingPointLat == nil || ride.MeetingPointLng == nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Ride has no start time or meeting point for location check-in",
+		})
+	}
+
+	var participant models.RideParticipant
+	if err := database.DB.Where("ride_id = ? AND user_id = ?", rideID, user.ID).First(&participant).Error; err != nil {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "Not registered for this ride",
+		})
+	}
+
+	var req LocationCheckInRequest
+	if err := c.BodyParser(&req); err != nil || req.Lat == nil || req.Lng == nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "lat and lng are required",
+		})
+	}
+
+	now := time.Now()
+	cfg := config.AppConfig
+	windowStart := ride.StartTime.Add(-time.Duration(cfg.CheckInWindowBeforeMinutes) * time.Minute)
+	windowEnd := ride.StartTime.Add(time.Duration(cfg.CheckInWindowAfterMinutes) * time.Minute)
+	if now.Before(windowStart) || now.After(windowEnd) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":        "Check-in is not open at this time",
+			"window_start": windowStart,
+			"window_end":   windowEnd,
+		})
+	}
+
+	radius := float64(cfg.CheckInRadiusMeters)
+	if ride.CheckInRadiusMeters != nil && *ride.CheckInRadiusMeters > 0 {
+		radius = float64(*ride.CheckInRadiusMeters)
+	}
+
+	if req.AccuracyMeters < 0 || req.AccuracyMeters > radius {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Location accuracy is too low to check in",
+		})
+	}
+
+	distance := gpx.HaversineDistance(*ride.MeetingPointLat, *ride.MeetingPointLng, *req.Lat, *req.Lng) * 1000
+	distance = math.Round(distance*100) / 100
+	if distance > radius {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":           "You are too far from the meeting point",
+			"distance_meters": distance,
+			"radius_meters":   radius,
+		})
+	}
+
+	if participant.CheckedInAt == nil {
+		accuracy := req.AccuracyMeters
+		participant.Attended = true
+		participant.CheckedInAt = &now
+		participant.CheckInMethod = models.CheckInMethodLocation
+		participant.CheckInLat = req.Lat
+		participant.CheckInLng = req.Lng
+		participant.CheckInAccuracyMeters = &accuracy
+		participant.CheckInDistanceMeters = &distance
+
+		if err := database.DB.Save(&participant).Error; err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to check in",
+			})
+		}
+	}
+
+	database.DB.Preload("User").First(&participant, "id = ?", participant.ID)
+
+	return c.JSON(participant.ToResponse(user.IsAdmin))
+}
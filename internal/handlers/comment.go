@@ -0,0 +1,287 @@
+package handlers
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/udacc/uda-cycling-club/internal/database"
+	"github.com/udacc/uda-cycling-club/internal/middleware"
+	"github.com/udacc/uda-cycling-club/internal/models"
+	"gorm.io/gorm"
+)
+
+const maxCommentLength = 5000
+
+type CommentRequest struct {
+	Body     string      `json:"body"`
+	ParentID *uuid.UUID  `json:"parent_id"`
+	Mentions []uuid.UUID `json:"mentions"`
+}
+
+// preloadCommentUsers loads the author and mentioned users of a comment query
+func preloadCommentUsers(db *gorm.DB) *gorm.DB {
+	return db.
+		Preload("User").
+		Preload("Mentions.User")
+}
+
+// setMentions replaces the comment's mentions with the given existing users.
+// Call it in the transaction that saves the comment.
+func setMentions(db *gorm.DB, commentID uuid.UUID, userIDs []uuid.UUID) error {
+	if err := db.Where("comment_id = ?", commentID).Delete(&models.CommentMention{}).Error; err != nil {
+		return err
+	}
+	if len(userIDs) == 0 {
+		return nil
+	}
+
+	var existing []uuid.UUID
+	if err := db.Model(&models.User{}).Where("id IN ?", userIDs).Pluck("id", &existing).Error; err != nil {
+		return err
+	}
+
+	for _, userID := range existing {
+		mention := models.CommentMention{CommentID: commentID, UserID: userID}
+		if err := db.Create(&mention).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func ListComments(c *fiber.Ctx) error {
+	rideID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid ride ID",
+		})
+	}
+
+	limit, _ := strconv.Atoi(c.Query("limit", "20"))
+	offset, _ := strconv.Atoi(c.Query("offset", "0"))
+
+	query := database.DB.Model(&models.RideComment{}).Where("ride_id = ? AND parent_id IS NULL", rideID)
+
+	var total int64
+	query.Count(&total)
+
+	var comments []models.RideComment
+	preloadCommentUsers(query).
+		Preload("Replies", func(db *gorm.DB) *gorm.DB {
+			return db.Order("created_at")
+		}).
+		Preload("Replies.User").
+		Preload("Replies.Mentions.User").
+		Order("created_at").
+		Limit(limit).
+		Offset(offset).
+		Find(&comments)
+
+	isAdmin := middleware.IsAdmin(c)
+	responses := make([]models.CommentResponse, len(comments))
+	for i, comment := range comments {
+		responses[i] = comment.ToResponse(isAdmin)
+	}
+
+	return c.JSON(fiber.Map{
+		"comments": responses,
+		"total":    total,
+		"limit":    limit,
+		"offset":   offset,
+	})
+}
+
+func CreateComment(c *fiber.Ctx) error {
+	user := middleware.GetCurrentUser(c)
+
+	rideID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid ride ID",
+		})
+	}
+
+	var ride models.Ride
+	if err := database.DB.First(&ride, "id = ?", rideID).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Ride not found",
+		})
+	}
+
+	if ride.Status == models.RideStatusDraft && !isRideStaff(&ride, user) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "Cannot comment on a draft ride",
+		})
+	}
+
+	var req CommentRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	req.Body = strings.TrimSpace(req.Body)
+	if req.Body == "" || len([]rune(req.Body)) > maxCommentLength {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Comment body is required and must be at most 5000 characters",
+		})
+	}
+
+	comment := models.RideComment{
+		RideID: rideID,
+		UserID: user.ID,
+		Body:   req.Body,
+	}
+
+	if req.ParentID != nil {
+		var parent models.RideComment
+		if err := database.DB.Where("id = ? AND ride_id = ?", *req.ParentID, rideID).First(&parent).Error; err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Parent comment not found",
+			})
+		}
+		// Threads are one level deep; replies to a reply join its thread
+		if parent.ParentID != nil {
+			comment.ParentID = parent.ParentID
+		} else {
+			comment.ParentID = &parent.ID
+		}
+	}
+
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&comment).Error; err != nil {
+			return err
+		}
+		return setMentions(tx, comment.ID, req.Mentions)
+	})
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to create comment",
+		})
+	}
+
+	preloadCommentUsers(database.DB).First(&comment, "id = ?", comment.ID)
+
+	return c.Status(fiber.StatusCreated).JSON(comment.ToResponse(user.IsAdmin))
+}
+
+func UpdateComment(c *fiber.Ctx) error {
+	user := middleware.GetCurrentUser(c)
+
+	rideID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid ride ID",
+		})
+	}
+
+	commentID, err := uuid.Parse(c.Params("cid"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid comment ID",
+		})
+	}
+
+	var comment models.RideComment
+	if err := database.DB.Where("id = ? AND ride_id = ?", commentID, rideID).First(&comment).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Comment not found",
+		})
+	}
+
+	if comment.UserID != user.ID {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "You can only edit your own comments",
+		})
+	}
+
+	var req CommentRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	req.Body = strings.TrimSpace(req.Body)
+	if req.Body == "" || len([]rune(req.Body)) > maxCommentLength {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Comment body is required and must be at most 5000 characters",
+		})
+	}
+
+	now := time.Now()
+	comment.Body = req.Body
+	comment.EditedAt = &now
+
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&comment).Error; err != nil {
+			return err
+		}
+		if req.Mentions == nil {
+			return nil
+		}
+		return setMentions(tx, comment.ID, req.Mentions)
+	})
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to update comment",
+		})
+	}
+
+	preloadCommentUsers(database.DB).First(&comment, "id = ?", comment.ID)
+
+	return c.JSON(comment.ToResponse(user.IsAdmin))
+}
+
+func DeleteComment(c *fiber.Ctx) error {
+	user := middleware.GetCurrentUser(c)
+
+	rideID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid ride ID",
+		})
+	}
+
+	commentID, err := uuid.Parse(c.Params("cid"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid comment ID",
+		})
+	}
+
+	var ride models.Ride
+	if err := database.DB.First(&ride, "id = ?", rideID).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Ride not found",
+		})
+	}
+
+	var comment models.RideComment
+	if err := database.DB.Where("id = ? AND ride_id = ?", commentID, rideID).First(&comment).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Comment not found",
+		})
+	}
+
+	if comment.UserID != user.ID && !isRideStaff(&ride, user) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "Only the author or ride staff can delete a comment",
+		})
+	}
+
+	// Deleting a thread's root removes its replies as well
+	if err := database.DB.Where("id = ? OR parent_id = ?", comment.ID, comment.ID).Delete(&models.RideComment{}).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to delete comment",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Comment deleted successfully",
+	})
+}
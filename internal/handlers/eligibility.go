@@ -0,0 +1,334 @@
+package handlers
+
+import (
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/udacc/uda-cycling-club/internal/database"
+	"github.com/udacc/uda-cycling-club/internal/middleware"
+	"github.com/udacc/uda-cycling-club/internal/models"
+	"github.com/udacc/uda-cycling-club/internal/services"
+)
+
+type EligibilityRuleRequest struct {
+	MinTotalDistanceKm       float64                 `json:"min_total_distance_km"`
+	MinCompletedRides        int                     `json:"min_completed_rides"`
+	MinCompletedRidesTypeID  *uint                   `json:"min_completed_rides_type_id"`
+	RequiredMembershipStatus models.MembershipStatus `json:"required_membership_status"`
+	RequiredRideID           *uuid.UUID              `json:"required_ride_id"`
+}
+
+type GrantOverrideRequest struct {
+	UserID uuid.UUID `json:"user_id"`
+	Reason string    `json:"reason"`
+}
+
+// applyRuleRequest copies the request onto the rule, validating referenced
+// records. It returns a non-empty message when the request is invalid.
+func applyRuleRequest(rule *models.EligibilityRule, req *EligibilityRuleRequest) string {
+	if req.MinTotalDistanceKm < 0 || req.MinCompletedRides < 0 {
+		return "Minimum values cannot be negative"
+	}
+	if req.RequiredMembershipStatus != "" && !req.RequiredMembershipStatus.IsValid() {
+		return "Membership status must be one of active, guest, inactive"
+	}
+	if req.MinCompletedRidesTypeID != nil {
+		var rideType models.RideType
+		if err := database.DB.First(&rideType, *req.MinCompletedRidesTypeID).Error; err != nil {
+			return "Invalid ride type"
+		}
+	}
+	if req.RequiredRideID != nil {
+		var required models.Ride
+		if err := database.DB.First(&required, "id = ?", *req.RequiredRideID).Error; err != nil {
+			return "Required ride not found"
+		}
+	}
+
+	rule.MinTotalDistanceKm = req.MinTotalDistanceKm
+	rule.MinCompletedRides = req.MinCompletedRides
+	rule.MinCompletedRidesTypeID = req.MinCompletedRidesTypeID
+	rule.RequiredMembershipStatus = req.RequiredMembershipStatus
+	rule.RequiredRideID = req.RequiredRideID
+	return ""
+}
+
+// GetEligibility returns the rules that apply to a ride. Ride leaders also
+// see the granted overrides, and authenticated users see whether they are
+// currently eligible.
+func GetEligibility(c *fiber.Ctx) error {
+	user := middleware.GetCurrentUser(c)
+
+	rideID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid ride ID",
+		})
+	}
+
+	var ride models.Ride
+	if err := database.DB.First(&ride, "id = ?", rideID).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Ride not found",
+		})
+	}
+
+	response := fiber.Map{}
+
+	var rideRule models.EligibilityRule
+	if err := database.DB.Where("ride_id = ?", ride.ID).First(&rideRule).Error; err == nil {
+		response["ride_rule"] = rideRule
+	}
+
+	var typeRule models.EligibilityRule
+	if err := database.DB.Where("ride_type_id = ?", ride.RideTypeID).First(&typeRule).Error; err == nil {
+		response["ride_type_rule"] = typeRule
+	}
+
+	if user != nil {
+		reasons := services.CheckEligibility(database.DB, &ride, user)
+		response["eligible"] = len(reasons) == 0
+		response["reasons"] = reasons
+
+		if isRideLeader(&ride, user) {
+			var overrides []models.EligibilityOverride
+			database.DB.Preload("User").Where("ride_id = ?", ride.ID).Order("created_at").Find(&overrides)
+
+			overrideResponses := make([]models.EligibilityOverrideResponse, len(overrides))
+			for i, o := range overrides {
+				overrideResponses[i] = o.ToResponse(user.IsAdmin)
+			}
+			response["overrides"] = overrideResponses
+		}
+	}
+
+	return c.JSON(response)
+}
+
+func SetRideEligibility(c *fiber.Ctx) error {
+	user := middleware.GetCurrentUser(c)
+
+	rideID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid ride ID",
+		})
+	}
+
+	var ride models.Ride
+	if err := database.DB.First(&ride, "id = ?", rideID).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Ride not found",
+		})
+	}
+
+	if !isRideLeader(&ride, user) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "Only ride leaders can change eligibility rules",
+		})
+	}
+
+	var req EligibilityRuleRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	var rule models.EligibilityRule
+	if err := database.DB.Where("ride_id = ?", ride.ID).First(&rule).Error; err != nil {
+		rule = models.EligibilityRule{RideID: &ride.ID}
+	}
+
+	if msg := applyRuleRequest(&rule, &req); msg != "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": msg,
+		})
+	}
+
+	if err := database.DB.Save(&rule).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to save eligibility rule",
+		})
+	}
+
+	return c.JSON(rule)
+}
+
+func DeleteRideEligibility(c *fiber.Ctx) error {
+	user := middleware.GetCurrentUser(c)
+
+	rideID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid ride ID",
+		})
+	}
+
+	var ride models.Ride
+	if err := database.DB.First(&ride, "id = ?", rideID).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Ride not found",
+		})
+	}
+
+	if !isRideLeader(&ride, user) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "Only ride leaders can change eligibility rules",
+		})
+	}
+
+	result := database.DB.Where("ride_id = ?", ride.ID).Delete(&models.EligibilityRule{})
+	if result.RowsAffected == 0 {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Ride has no eligibility rule",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Eligibility rule removed successfully",
+	})
+}
+
+func SetRideTypeEligibility(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid ride type ID",
+		})
+	}
+
+	var rideType models.RideType
+	if err := database.DB.First(&rideType, id).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Ride type not found",
+		})
+	}
+
+	var req EligibilityRuleRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	var rule models.EligibilityRule
+	if err := database.DB.Where("ride_type_id = ?", rideType.ID).First(&rule).Error; err != nil {
+		rule = models.EligibilityRule{RideTypeID: &rideType.ID}
+	}
+
+	if msg := applyRuleRequest(&rule, &req); msg != "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": msg,
+		})
+	}
+
+	if err := database.DB.Save(&rule).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to save eligibility rule",
+		})
+	}
+
+	return c.JSON(rule)
+}
+
+func GrantEligibilityOverride(c *fiber.Ctx) error {
+	user := middleware.GetCurrentUser(c)
+
+	rideID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid ride ID",
+		})
+	}
+
+	var ride models.Ride
+	if err := database.DB.First(&ride, "id = ?", rideID).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Ride not found",
+		})
+	}
+
+	if !isRideLeader(&ride, user) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "Only ride leaders can grant overrides",
+		})
+	}
+
+	var req GrantOverrideRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	var member models.User
+	if err := database.DB.First(&member, "id = ?", req.UserID).Error; err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "User not found",
+		})
+	}
+
+	var override models.EligibilityOverride
+	if err := database.DB.Where("ride_id = ? AND user_id = ?", ride.ID, member.ID).First(&override).Error; err != nil {
+		override = models.EligibilityOverride{
+			RideID: ride.ID,
+			UserID: member.ID,
+		}
+	}
+	override.GrantedByID = user.ID
+	override.Reason = req.Reason
+
+	if err := database.DB.Save(&override).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to grant override",
+		})
+	}
+
+	database.DB.Preload("User").First(&override, "id = ?", override.ID)
+
+	return c.Status(fiber.StatusCreated).JSON(override.ToResponse(user.IsAdmin))
+}
+
+func RevokeEligibilityOverride(c *fiber.Ctx) error {
+	user := middleware.GetCurrentUser(c)
+
+	rideID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid ride ID",
+		})
+	}
+
+	userID, err := uuid.Parse(c.Params("uid"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid user ID",
+		})
+	}
+
+	var ride models.Ride
+	if err := database.DB.First(&ride, "id = ?", rideID).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Ride not found",
+		})
+	}
+
+	if !isRideLeader(&ride, user) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "Only ride leaders can revoke overrides",
+		})
+	}
+
+	result := database.DB.Where("ride_id = ? AND user_id = ?", ride.ID, userID).Delete(&models.EligibilityOverride{})
+	if result.RowsAffected == 0 {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Override not found",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Override revoked successfully",
+	})
+}
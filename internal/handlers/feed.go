@@ -0,0 +1,150 @@
+package handlers
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/udacc/uda-cycling-club/internal/database"
+	"github.com/udacc/uda-cycling-club/internal/models"
+	"github.com/udacc/uda-cycling-club/internal/services"
+	"github.com/udacc/uda-cycling-club/pkg/atom"
+)
+
+const rideFeedSize = 50
+
+// rideFeedContent renders the entry body: description, route stats and
+// meeting point
+func rideFeedContent(ride *models.Ride) string {
+	var b strings.Builder
+
+	if ride.Description != "" {
+		fmt.Fprintf(&b, "<p>%s</p>", strings.ReplaceAll(html.EscapeString(ride.Description), "\n", "<br>"))
+	}
+
+	b.WriteString("<ul>")
+	if ride.StartTime != nil {
+		fmt.Fprintf(&b, "<li>Start: %s</li>", ride.StartTime.In(clubLocation()).Format("2006-01-02 15:04"))
+	}
+	if ride.RideType.ID != 0 {
+		fmt.Fprintf(&b, "<li>Type: %s</li>", html.EscapeString(ride.RideType.Name))
+	}
+	if ride.DistanceKm > 0 {
+		fmt.Fprintf(&b, "<li>Distance: %.1f km</li>", ride.DistanceKm)
+		fmt.Fprintf(&b, "<li>Elevation gain: %.0f m</li>", ride.ElevationGain)
+		fmt.Fprintf(&b, "<li>Max gradient: %.1f%%</li>", ride.MaxGradient)
+	}
+	if ride.PassCount > 0 {
+		fmt.Fprintf(&b, "<li>Passes: %d</li>", ride.PassCount)
+	}
+	fmt.Fprintf(&b, "<li>Difficulty: %d</li>", ride.DifficultyLevel)
+	if ride.MeetingPointName != "" {
+		fmt.Fprintf(&b, "<li>Meeting point: %s", html.EscapeString(ride.MeetingPointName))
+		if ride.MeetingPointLat != nil && ride.MeetingPointLng != nil {
+			fmt.Fprintf(&b, " (%.6f, %.6f)", *ride.MeetingPointLat, *ride.MeetingPointLng)
+		}
+		b.WriteString("</li>")
+	}
+	if ride.HasFee() {
+		fmt.Fprintf(&b, "<li>Fee: %.0f %s</li>", ride.FeeAmount, html.EscapeString(ride.FeeCurrency))
+	}
+	if ride.Status == models.RideStatusCancelled {
+		b.WriteString("<li><strong>Cancelled</strong></li>")
+	}
+	b.WriteString("</ul>")
+
+	return b.String()
+}
+
+// clubLocation is the time zone ride times are shown in
+func clubLocation() *time.Location {
+	return services.ClubLocation()
+}
+
+// RidesFeed is an Atom feed of rides in the order they were published.
+// Entries carry the ride's UpdatedAt so readers pick up later changes.
+func RidesFeed(c *fiber.Ctx) error {
+	query := database.DB.
+		Preload("RideType").
+		Preload("CreatedBy").
+		Where("status != ? AND published_at IS NOT NULL", models.RideStatusDraft)
+
+	selfURL := c.BaseURL() + "/api/v1/feeds/rides.atom"
+	feedID := "urn:udacc:feeds:rides"
+	title := "UDA Cycling Club rides"
+
+	if rideTypeID := c.QueryInt("ride_type_id"); rideTypeID > 0 {
+		var rideType models.RideType
+		if err := database.DB.First(&rideType, rideTypeID).Error; err != nil {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "Ride type not found",
+			})
+		}
+
+		query = query.Where("ride_type_id = ?", rideTypeID)
+		selfURL += "?ride_type_id=" + strconv.Itoa(rideTypeID)
+		feedID += ":type:" + strconv.Itoa(rideTypeID)
+		title += " - " + rideType.Name
+	}
+
+	var rides []models.Ride
+	query.
+		Order("published_at DESC").
+		Limit(rideFeedSize).
+		Find(&rides)
+
+	feed := atom.Feed{
+		ID:      feedID,
+		Title:   title,
+		Updated: atom.FormatTime(time.Unix(0, 0)),
+		Links:   []atom.Link{{Href: selfURL, Rel: "self", Type: "application/atom+xml"}},
+		Author:  &atom.Person{Name: "UDA Cycling Club"},
+		Entries: make([]atom.Entry, len(rides)),
+	}
+
+	var latest time.Time
+	for i := range rides {
+		ride := &rides[i]
+		if ride.UpdatedAt.After(latest) {
+			latest = ride.UpdatedAt
+		}
+
+		entry := atom.Entry{
+			ID:        "urn:uuid:" + ride.ID.String(),
+			Title:     ride.Title,
+			Updated:   atom.FormatTime(ride.UpdatedAt),
+			Published: atom.FormatTime(*ride.PublishedAt),
+			Links: []atom.Link{
+				{Href: c.BaseURL() + "/api/v1/rides/" + ride.ID.String(), Rel: "alternate", Type: "application/json"},
+			},
+			Content: &atom.Text{Type: "html", Body: rideFeedContent(ride)},
+		}
+		if ride.RideType.ID != 0 {
+			entry.Categories = []atom.Category{{Term: strconv.Itoa(int(ride.RideType.ID)), Label: ride.RideType.Name}}
+		}
+		if ride.CreatedBy.ID != uuid.Nil {
+			lastName, firstName := ride.CreatedBy.GetDisplayName(false)
+			entry.Author = &atom.Person{Name: strings.TrimSpace(firstName + " " + lastName)}
+		}
+
+		feed.Entries[i] = entry
+	}
+	if !latest.IsZero() {
+		feed.Updated = atom.FormatTime(latest)
+	}
+
+	var buf bytes.Buffer
+	if err := feed.Write(&buf); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to build feed",
+		})
+	}
+
+	c.Set(fiber.HeaderContentType, "application/atom+xml; charset=utf-8")
+	return c.Send(buf.Bytes())
+}
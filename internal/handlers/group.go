@@ -0,0 +1,385 @@
+package handlers
+
+import (
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/udacc/uda-cycling-club/internal/database"
+	"github.com/udacc/uda-cycling-club/internal/middleware"
+	"github.com/udacc/uda-cycling-club/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type RideGroupRequest struct {
+	Name          string     `json:"name"`
+	LeaderID      *uuid.UUID `json:"leader_id"`
+	TargetPaceKmh *float64   `json:"target_pace_kmh"`
+	Capacity      *int       `json:"capacity"`
+	SortOrder     *int       `json:"sort_order"`
+}
+
+type ChooseGroupRequest struct {
+	GroupID *uuid.UUID `json:"group_id"`
+}
+
+// groupMemberCount returns how many participants are in the group
+func groupMemberCount(groupID uuid.UUID) int {
+	var count int64
+	database.DB.Model(&models.RideParticipant{}).Where("group_id = ?", groupID).Count(&count)
+	return int(count)
+}
+
+// groupUnavailableError is returned from a transaction when the chosen
+// group can't be joined. Its text is shown to the user.
+type groupUnavailableError string
+
+func (e groupUnavailableError) Error() string {
+	return string(e)
+}
+
+// lockOpenGroup locks a group of the ride and checks it still has room. Call
+// it in the transaction that assigns the participant, so concurrent joins
+// can't overfill the group.
+func lockOpenGroup(tx *gorm.DB, rideID, groupID uuid.UUID) error {
+	var group models.RideGroup
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ? AND ride_id = ?", groupID, rideID).
+		First(&group).Error; err != nil {
+		return groupUnavailableError("Group not found")
+	}
+
+	var count int64
+	if err := tx.Model(&models.RideParticipant{}).Where("group_id = ?", groupID).Count(&count).Error; err != nil {
+		return err
+	}
+	if group.IsFull(int(count)) {
+		return groupUnavailableError("Group is full")
+	}
+	return nil
+}
+
+// ledGroupIDs returns the groups of the ride led by the user
+func ledGroupIDs(rideID, userID uuid.UUID) []uuid.UUID {
+	var ids []uuid.UUID
+	database.DB.Model(&models.RideGroup{}).
+		Where("ride_id = ? AND leader_id = ?", rideID, userID).
+		Pluck("id", &ids)
+	return ids
+}
+
+// isGroupLeaderOf reports whether the user leads the participant's group
+func isGroupLeaderOf(participant *models.RideParticipant, user *models.User) bool {
+	if participant.GroupID == nil {
+		return false
+	}
+
+	var count int64
+	database.DB.Model(&models.RideGroup{}).
+		Where("id = ? AND leader_id = ?", *participant.GroupID, user.ID).
+		Count(&count)
+	return count > 0
+}
+
+func ListGroups(c *fiber.Ctx) error {
+	rideID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid ride ID",
+		})
+	}
+
+	var groups []models.RideGroup
+	database.DB.
+		Preload("Leader").
+		Where("ride_id = ?", rideID).
+		Order("sort_order").
+		Find(&groups)
+
+	isAdmin := middleware.IsAdmin(c)
+	responses := make([]models.RideGroupResponse, len(groups))
+	for i, g := range groups {
+		responses[i] = g.ToResponse(isAdmin, groupMemberCount(g.ID))
+	}
+
+	return c.JSON(fiber.Map{
+		"groups": responses,
+	})
+}
+
+func CreateGroup(c *fiber.Ctx) error {
+	user := middleware.GetCurrentUser(c)
+
+	rideID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid ride ID",
+		})
+	}
+
+	var ride models.Ride
+	if err := database.DB.First(&ride, "id = ?", rideID).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Ride not found",
+		})
+	}
+
+	if !isRideLeader(&ride, user) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "Only ride leaders can manage groups",
+		})
+	}
+
+	if ride.Status == models.RideStatusCompleted || ride.Status == models.RideStatusCancelled {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Cannot change groups of completed or cancelled ride",
+		})
+	}
+
+	var req RideGroupRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	if req.Name == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Name is required",
+		})
+	}
+
+	group := models.RideGroup{
+		RideID: ride.ID,
+		Name:   req.Name,
+	}
+	if msg := applyGroupRequest(&group, &req); msg != "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": msg,
+		})
+	}
+
+	if err := database.DB.Create(&group).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to create group",
+		})
+	}
+
+	database.DB.Preload("Leader").First(&group, "id = ?", group.ID)
+
+	return c.Status(fiber.StatusCreated).JSON(group.ToResponse(user.IsAdmin, 0))
+}
+
+func UpdateGroup(c *fiber.Ctx) error {
+	user := middleware.GetCurrentUser(c)
+
+	rideID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid ride ID",
+		})
+	}
+
+	groupID, err := uuid.Parse(c.Params("gid"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid group ID",
+		})
+	}
+
+	var ride models.Ride
+	if err := database.DB.First(&ride, "id = ?", rideID).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Ride not found",
+		})
+	}
+
+	if !isRideLeader(&ride, user) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "Only ride leaders can manage groups",
+		})
+	}
+
+	var group models.RideGroup
+	if err := database.DB.Where("id = ? AND ride_id = ?", groupID, rideID).First(&group).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Group not found",
+		})
+	}
+
+	var req RideGroupRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	if req.Name != "" {
+		group.Name = req.Name
+	}
+	if msg := applyGroupRequest(&group, &req); msg != "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": msg,
+		})
+	}
+
+	memberCount := groupMemberCount(group.ID)
+	if group.Capacity != nil && *group.Capacity < memberCount {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Capacity cannot be lower than the current member count",
+		})
+	}
+
+	if err := database.DB.Save(&group).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to update group",
+		})
+	}
+
+	database.DB.Preload("Leader").First(&group, "id = ?", group.ID)
+
+	return c.JSON(group.ToResponse(user.IsAdmin, memberCount))
+}
+
+// applyGroupRequest copies the optional fields of the request onto the group.
+// It returns a non-empty message when the request is invalid.
+func applyGroupRequest(group *models.RideGroup, req *RideGroupRequest) string {
+	if req.LeaderID != nil {
+		var leader models.User
+		if err := database.DB.First(&leader, "id = ?", *req.LeaderID).Error; err != nil {
+			return "Group leader not found"
+		}
+		group.LeaderID = req.LeaderID
+		group.Leader = nil
+	}
+	if req.TargetPaceKmh != nil {
+		if *req.TargetPaceKmh < 0 {
+			return "Target pace cannot be negative"
+		}
+		group.TargetPaceKmh = *req.TargetPaceKmh
+	}
+	if req.Capacity != nil {
+		if *req.Capacity <= 0 {
+			return "Capacity must be positive"
+		}
+		group.Capacity = req.Capacity
+	}
+	if req.SortOrder != nil {
+		group.SortOrder = *req.SortOrder
+	}
+	return ""
+}
+
+func DeleteGroup(c *fiber.Ctx) error {
+	user := middleware.GetCurrentUser(c)
+
+	rideID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid ride ID",
+		})
+	}
+
+	groupID, err := uuid.Parse(c.Params("gid"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid group ID",
+		})
+	}
+
+	var ride models.Ride
+	if err := database.DB.First(&ride, "id = ?", rideID).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Ride not found",
+		})
+	}
+
+	if !isRideLeader(&ride, user) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "Only ride leaders can manage groups",
+		})
+	}
+
+	result := database.DB.Where("id = ? AND ride_id = ?", groupID, rideID).Delete(&models.RideGroup{})
+	if result.RowsAffected == 0 {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Group not found",
+		})
+	}
+
+	// Members of a removed group go back to the ungrouped list
+	database.DB.Model(&models.RideParticipant{}).
+		Where("ride_id = ? AND group_id = ?", rideID, groupID).
+		Update("group_id", nil)
+
+	return c.JSON(fiber.Map{
+		"message": "Group deleted successfully",
+	})
+}
+
+// ChooseGroup moves the current user's registration to another group, or
+// out of any group when group_id is null
+func ChooseGroup(c *fiber.Ctx) error {
+	user := middleware.GetCurrentUser(c)
+
+	rideID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid ride ID",
+		})
+	}
+
+	var ride models.Ride
+	if err := database.DB.First(&ride, "id = ?", rideID).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Ride not found",
+		})
+	}
+
+	if ride.Status != models.RideStatusPublished && ride.Status != models.RideStatusOngoing {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Can only change group for published or ongoing rides",
+		})
+	}
+
+	var participant models.RideParticipant
+	if err := database.DB.Where("ride_id = ? AND user_id = ?", rideID, user.ID).First(&participant).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Not registered for this ride",
+		})
+	}
+
+	var req ChooseGroupRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if req.GroupID != nil && (participant.GroupID == nil || *participant.GroupID != *req.GroupID) {
+			if err := lockOpenGroup(tx, rideID, *req.GroupID); err != nil {
+				return err
+			}
+		}
+
+		participant.GroupID = req.GroupID
+		return tx.Save(&participant).Error
+	})
+	var groupErr groupUnavailableError
+	if errors.As(err, &groupErr) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": string(groupErr),
+		})
+	}
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to change group",
+		})
+	}
+
+	database.DB.Preload("User").First(&participant, "id = ?", participant.ID)
+
+	return c.JSON(participant.ToResponse(user.IsAdmin))
+}
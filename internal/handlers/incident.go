@@ -0,0 +1,481 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/udacc/uda-cycling-club/internal/database"
+	"github.com/udacc/uda-cycling-club/internal/middleware"
+	"github.com/udacc/uda-cycling-club/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type IncidentRequest struct {
+	Type                models.IncidentType     `json:"type"`
+	Severity            models.IncidentSeverity `json:"severity"`
+	Description         string                  `json:"description"`
+	OccurredAt          string                  `json:"occurred_at"`
+	LocationDescription string                  `json:"location_description"`
+	Lat                 *float64                `json:"lat"`
+	Lng                 *float64                `json:"lng"`
+	ActionsTaken        string                  `json:"actions_taken"`
+	InvolvedUserIDs     []uuid.UUID             `json:"involved_user_ids"`
+}
+
+type ReviewIncidentRequest struct {
+	Notes string `json:"notes"`
+}
+
+// applyIncidentRequest validates the request and copies it onto the incident.
+// Involved users must be registered for the ride. It returns an error
+// message, or "" if the request is valid.
+func applyIncidentRequest(incident *models.RideIncident, req *IncidentRequest) string {
+	if req.Type != "" {
+		if !req.Type.IsValid() {
+			return "Invalid incident type"
+		}
+		incident.Type = req.Type
+	}
+	if req.Severity != "" {
+		if !req.Severity.IsValid() {
+			return "Invalid incident severity"
+		}
+		incident.Severity = req.Severity
+	}
+	if incident.Type == "" || incident.Severity == "" {
+		return "type and severity are required"
+	}
+
+	if req.OccurredAt != "" {
+		occurredAt, err := time.Parse(time.RFC3339, req.OccurredAt)
+		if err != nil {
+			return "Invalid occurred_at format. Use RFC3339 format"
+		}
+		incident.OccurredAt = &occurredAt
+	}
+
+	if (req.Lat == nil) != (req.Lng == nil) {
+		return "lat and lng must be given together"
+	}
+	if req.Lat != nil {
+		incident.Lat = req.Lat
+		incident.Lng = req.Lng
+	}
+
+	if req.Description != "" {
+		incident.Description = req.Description
+	}
+	if req.LocationDescription != "" {
+		incident.LocationDescription = req.LocationDescription
+	}
+	if req.ActionsTaken != "" {
+		incident.ActionsTaken = req.ActionsTaken
+	}
+
+	if req.InvolvedUserIDs != nil {
+		userIDs := make([]uuid.UUID, 0, len(req.InvolvedUserIDs))
+		for _, id := range req.InvolvedUserIDs {
+			if !containsUUID(userIDs, id) {
+				userIDs = append(userIDs, id)
+			}
+		}
+
+		var involved []models.User
+		if len(userIDs) > 0 {
+			database.DB.
+				Joins("JOIN ride_participants ON ride_participants.user_id = users.id").
+				Where("ride_participants.ride_id = ? AND users.id IN ?", incident.RideID, userIDs).
+				Find(&involved)
+			if len(involved) != len(userIDs) {
+				return "Involved users must be registered for the ride"
+			}
+		}
+		incident.Involved = involved
+	}
+
+	return ""
+}
+
+func loadIncident(id uuid.UUID) (*models.RideIncident, error) {
+	var incident models.RideIncident
+	err := database.DB.
+		Preload("Ride").
+		Preload("ReportedBy").
+		Preload("ReviewedBy").
+		Preload("Involved").
+		First(&incident, "id = ?", id).Error
+	return &incident, err
+}
+
+func CreateIncident(c *fiber.Ctx) error {
+	user := middleware.GetCurrentUser(c)
+
+	rideID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid ride ID",
+		})
+	}
+
+	var ride models.Ride
+	if err := database.DB.First(&ride, "id = ?", rideID).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Ride not found",
+		})
+	}
+
+	if !isRideStaff(&ride, user) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "Only ride staff can report incidents",
+		})
+	}
+
+	if ride.Status != models.RideStatusOngoing && ride.Status != models.RideStatusCompleted {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Incidents can only be reported for ongoing or completed rides",
+		})
+	}
+
+	var req IncidentRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	incident := models.RideIncident{
+		RideID:       rideID,
+		ReportedByID: user.ID,
+		Status:       models.IncidentStatusOpen,
+	}
+
+	if msg := applyIncidentRequest(&incident, &req); msg != "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": msg,
+		})
+	}
+
+	if err := database.DB.Create(&incident).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to report incident",
+		})
+	}
+
+	created, _ := loadIncident(incident.ID)
+
+	return c.Status(fiber.StatusCreated).JSON(created.ToResponse())
+}
+
+func ListRideIncidents(c *fiber.Ctx) error {
+	user := middleware.GetCurrentUser(c)
+
+	rideID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid ride ID",
+		})
+	}
+
+	var ride models.Ride
+	if err := database.DB.First(&ride, "id = ?", rideID).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Ride not found",
+		})
+	}
+
+	if !isRideStaff(&ride, user) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "Only ride staff can view incidents",
+		})
+	}
+
+	var incidents []models.RideIncident
+	database.DB.
+		Preload("ReportedBy").
+		Preload("ReviewedBy").
+		Preload("Involved").
+		Where("ride_id = ?", rideID).
+		Order("created_at").
+		Find(&incidents)
+
+	responses := make([]models.IncidentResponse, len(incidents))
+	for i, incident := range incidents {
+		responses[i] = incident.ToResponse()
+	}
+
+	return c.JSON(fiber.Map{
+		"incidents": responses,
+	})
+}
+
+func UpdateIncident(c *fiber.Ctx) error {
+	user := middleware.GetCurrentUser(c)
+
+	rideID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid ride ID",
+		})
+	}
+
+	incidentID, err := uuid.Parse(c.Params("iid"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid incident ID",
+		})
+	}
+
+	incident, err := loadIncident(incidentID)
+	if err != nil || incident.RideID != rideID {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Incident not found",
+		})
+	}
+
+	if !isRideStaff(&incident.Ride, user) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "Only ride staff can update incidents",
+		})
+	}
+
+	if incident.Status == models.IncidentStatusReviewed && !user.IsAdmin {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Reviewed incidents can only be changed by an admin",
+		})
+	}
+
+	var req IncidentRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	if msg := applyIncidentRequest(incident, &req); msg != "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": msg,
+		})
+	}
+
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit(clause.Associations).Save(incident).Error; err != nil {
+			return err
+		}
+		if req.InvolvedUserIDs != nil {
+			return tx.Model(incident).Association("Involved").Replace(incident.Involved)
+		}
+		return nil
+	})
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to update incident",
+		})
+	}
+
+	updated, _ := loadIncident(incident.ID)
+
+	return c.JSON(updated.ToResponse())
+}
+
+// filterIncidents applies the admin list and export query filters
+func filterIncidents(c *fiber.Ctx, query *gorm.DB) (*gorm.DB, string) {
+	if status := c.Query("status"); status != "" {
+		query = query.Where("ride_incidents.status = ?", status)
+	}
+	if severity := c.Query("severity"); severity != "" {
+		query = query.Where("ride_incidents.severity = ?", severity)
+	}
+	if incidentType := c.Query("type"); incidentType != "" {
+		query = query.Where("ride_incidents.type = ?", incidentType)
+	}
+	if rideID := c.Query("ride_id"); rideID != "" {
+		query = query.Where("ride_incidents.ride_id = ?", rideID)
+	}
+	if from := c.Query("from"); from != "" {
+		fromTime, err := time.Parse(time.RFC3339, from)
+		if err != nil {
+			return nil, "Invalid from format. Use RFC3339 format"
+		}
+		query = query.Where("ride_incidents.created_at >= ?", fromTime)
+	}
+	if to := c.Query("to"); to != "" {
+		toTime, err := time.Parse(time.RFC3339, to)
+		if err != nil {
+			return nil, "Invalid to format. Use RFC3339 format"
+		}
+		query = query.Where("ride_incidents.created_at < ?", toTime)
+	}
+	return query, ""
+}
+
+func ListIncidents(c *fiber.Ctx) error {
+	limit, _ := strconv.Atoi(c.Query("limit", "20"))
+	offset, _ := strconv.Atoi(c.Query("offset", "0"))
+
+	query, msg := filterIncidents(c, database.DB.Model(&models.RideIncident{}))
+	if msg != "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": msg,
+		})
+	}
+
+	var total int64
+	query.Count(&total)
+
+	var incidents []models.RideIncident
+	query.
+		Preload("Ride").
+		Preload("ReportedBy").
+		Preload("ReviewedBy").
+		Preload("Involved").
+		Order("created_at DESC").
+		Limit(limit).
+		Offset(offset).
+		Find(&incidents)
+
+	responses := make([]models.IncidentResponse, len(incidents))
+	for i, incident := range incidents {
+		responses[i] = incident.ToResponse()
+	}
+
+	return c.JSON(fiber.Map{
+		"incidents": responses,
+		"total":     total,
+		"limit":     limit,
+		"offset":    offset,
+	})
+}
+
+func ReviewIncident(c *fiber.Ctx) error {
+	user := middleware.GetCurrentUser(c)
+
+	incidentID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid incident ID",
+		})
+	}
+
+	incident, err := loadIncident(incidentID)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Incident not found",
+		})
+	}
+
+	var req ReviewIncidentRequest
+	c.BodyParser(&req)
+
+	now := time.Now()
+	incident.Status = models.IncidentStatusReviewed
+	incident.ReviewedByID = &user.ID
+	incident.ReviewedBy = nil
+	incident.ReviewedAt = &now
+	incident.ReviewNotes = req.Notes
+
+	if err := database.DB.Omit(clause.Associations).Save(incident).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to review incident",
+		})
+	}
+
+	reviewed, _ := loadIncident(incident.ID)
+
+	return c.JSON(reviewed.ToResponse())
+}
+
+// ExportIncidents downloads the filtered incidents as CSV
+func ExportIncidents(c *fiber.Ctx) error {
+	query, msg := filterIncidents(c, database.DB.Model(&models.RideIncident{}))
+	if msg != "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": msg,
+		})
+	}
+
+	var incidents []models.RideIncident
+	query.
+		Preload("Ride").
+		Preload("ReportedBy").
+		Preload("ReviewedBy").
+		Preload("Involved").
+		Order("created_at").
+		Find(&incidents)
+
+	c.Set(fiber.HeaderContentType, "text/csv; charset=utf-8")
+	c.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="incidents-%s.csv"`, time.Now().Format("20060102")))
+
+	w := csv.NewWriter(c.Response().BodyWriter())
+	w.Write([]string{
+		"id", "ride_id", "ride_title", "ride_start_time", "type", "severity",
+		"occurred_at", "location", "lat", "lng", "description", "actions_taken",
+		"involved", "reported_by", "status", "reviewed_by", "reviewed_at",
+		"review_notes", "created_at",
+	})
+
+	for _, incident := range incidents {
+		involved := make([]string, len(incident.Involved))
+		for i, u := range incident.Involved {
+			involved[i] = u.LastName + " " + u.FirstName
+		}
+
+		reviewedBy := ""
+		if incident.ReviewedBy != nil {
+			reviewedBy = incident.ReviewedBy.LastName + " " + incident.ReviewedBy.FirstName
+		}
+
+		w.Write([]string{
+			incident.ID.String(),
+			incident.RideID.String(),
+			formatCSVText(incident.Ride.Title),
+			formatCSVTime(incident.Ride.StartTime),
+			string(incident.Type),
+			string(incident.Severity),
+			formatCSVTime(incident.OccurredAt),
+			formatCSVText(incident.LocationDescription),
+			formatCSVFloat(incident.Lat),
+			formatCSVFloat(incident.Lng),
+			formatCSVText(incident.Description),
+			formatCSVText(incident.ActionsTaken),
+			formatCSVText(strings.Join(involved, "; ")),
+			formatCSVText(incident.ReportedBy.LastName + " " + incident.ReportedBy.FirstName),
+			string(incident.Status),
+			formatCSVText(reviewedBy),
+			formatCSVTime(incident.ReviewedAt),
+			formatCSVText(incident.ReviewNotes),
+			incident.CreatedAt.Format(time.RFC3339),
+		})
+	}
+
+	w.Flush()
+	return w.Error()
+}
+
+// formatCSVText stops spreadsheets from reading user-entered text as a
+// formula by prefixing cells that start with a formula character
+func formatCSVText(value string) string {
+	if value != "" && strings.ContainsRune("=+-@\t\r", rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
+
+func formatCSVTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
+
+func formatCSVFloat(f *float64) string {
+	if f == nil {
+		return ""
+	}
+	return strconv.FormatFloat(*f, 'f', -1, 64)
+}
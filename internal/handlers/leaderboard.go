@@ -0,0 +1,249 @@
+package handlers
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/udacc/uda-cycling-club/internal/database"
+	"github.com/udacc/uda-cycling-club/internal/middleware"
+	"github.com/udacc/uda-cycling-club/internal/models"
+	"github.com/udacc/uda-cycling-club/internal/services"
+	"github.com/udacc/uda-cycling-club/pkg/streak"
+	"gorm.io/gorm"
+)
+
+type leaderboardRow struct {
+	UserID uuid.UUID `json:"user_id"`
+	Value  float64   `json:"value"`
+	Rank   int       `json:"rank"`
+}
+
+// leaderboardPeriod returns the [from, to) range for the period parameter,
+// in club time. Both are nil for all time.
+func leaderboardPeriod(c *fiber.Ctx, now time.Time) (*time.Time, *time.Time, string) {
+	now = now.In(clubLocation())
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+
+	var from, to time.Time
+	switch c.Query("period", "all") {
+	case "all":
+		return nil, nil, ""
+	case "week":
+		// Weeks start on Monday
+		from = today.AddDate(0, 0, -((int(today.Weekday()) + 6) % 7))
+		to = from.AddDate(0, 0, 7)
+	case "month":
+		from = time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+		to = from.AddDate(0, 1, 0)
+	case "year":
+		from = time.Date(now.Year(), time.January, 1, 0, 0, 0, 0, now.Location())
+		to = from.AddDate(1, 0, 0)
+	case "season":
+		from, to = services.SeasonRange(now)
+	case "custom":
+		var ok bool
+		if from, ok = parseSearchTime(c.Query("from"), false); !ok {
+			return nil, nil, "Invalid from. Use RFC3339 or YYYY-MM-DD"
+		}
+		if to, ok = parseSearchTime(c.Query("to"), true); !ok {
+			return nil, nil, "Invalid to. Use RFC3339 or YYYY-MM-DD"
+		}
+		if !from.Before(to) {
+			return nil, nil, "from must be before to"
+		}
+	default:
+		return nil, nil, "Period must be one of all, week, month, season, year, custom"
+	}
+
+	return &from, &to, ""
+}
+
+// leaderboardScores sums the metric per user over completed rides. Rides
+// are placed in a period by when they were ridden. All-time distance and
+// ride counts come from the members' totals, so they include ledger
+// corrections and adjustments.
+func leaderboardScores(metric string, rideTypeID int, from, to *time.Time) (*gorm.DB, string) {
+	var query *gorm.DB
+
+	if (metric == "distance" || metric == "rides") && rideTypeID == 0 && from == nil {
+		value := map[string]string{
+			"distance": "users.total_distance_km",
+			"rides":    "users.total_rides",
+		}[metric]
+
+		return database.DB.Table("users").
+			Select("users.id AS user_id, " + value + " AS value").
+			Where("users.deleted_at IS NULL"), ""
+	}
+
+	switch metric {
+	case "distance", "rides", "elevation":
+		value := map[string]string{
+			"distance":  "SUM(ride_participants.final_distance_km)",
+			"rides":     "COUNT(*)",
+			"elevation": "SUM(rides.elevation_gain)",
+		}[metric]
+
+		query = database.DB.Table("ride_participants").
+			Select("ride_participants.user_id AS user_id, "+value+" AS value").
+			Joins("JOIN rides ON rides.id = ride_participants.ride_id").
+			Joins("JOIN users ON users.id = ride_participants.user_id AND users.deleted_at IS NULL").
+			Where("ride_participants.completed = ? AND rides.status = ?", true, models.RideStatusCompleted).
+			Group("ride_participants.user_id")
+	case "led":
+		query = database.DB.Table("rides").
+			Select("rides.leader_id AS user_id, COUNT(*) AS value").
+			Joins("JOIN users ON users.id = rides.leader_id AND users.deleted_at IS NULL").
+			Where("rides.status = ?", models.RideStatusCompleted).
+			Group("rides.leader_id")
+	default:
+		return nil, "Metric must be one of distance, rides, elevation, led"
+	}
+
+	if rideTypeID > 0 {
+		query = query.Where("rides.ride_type_id = ?", rideTypeID)
+	}
+	if from != nil {
+		query = query.Where(services.RideDate+" >= ? AND "+services.RideDate+" < ?", *from, *to)
+	}
+
+	return query, ""
+}
+
+// GetLeaderboard ranks members by distance, ride count, elevation or rides
+// led, optionally within a period and ride type
+func GetLeaderboard(c *fiber.Ctx) error {
+	from, to, msg := leaderboardPeriod(c, time.Now())
+	if msg != "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": msg,
+		})
+	}
+
+	metric := c.Query("metric", "distance")
+	rideTypeID := c.QueryInt("ride_type_id")
+
+	scores, msg := leaderboardScores(metric, rideTypeID, from, to)
+	if msg != "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": msg,
+		})
+	}
+
+	return sendLeaderboard(c, scores, fiber.Map{
+		"metric":       metric,
+		"period":       c.Query("period", "all"),
+		"from":         from,
+		"to":           to,
+		"ride_type_id": rideTypeID,
+	})
+}
+
+// sendLeaderboard ranks the per-user values selected by scores (user_id,
+// value) and writes a page of them. Tied members share a rank and are
+// listed by user ID so pages stay stable. The signed-in member's own rank
+// is returned as "me" even when it falls outside the page.
+func sendLeaderboard(c *fiber.Ctx, scores *gorm.DB, extra fiber.Map) error {
+	limit, _ := strconv.Atoi(c.Query("limit", "20"))
+	offset, _ := strconv.Atoi(c.Query("offset", "0"))
+
+	ranked := database.DB.Table("(?) AS scores", scores).
+		Select("user_id, value, RANK() OVER (ORDER BY value DESC) AS rank").
+		Where("value > 0")
+
+	var total int64
+	database.DB.Table("(?) AS ranked", ranked).Count(&total)
+
+	var rows []leaderboardRow
+	database.DB.Table("(?) AS ranked", ranked).
+		Order("rank, user_id").
+		Limit(limit).
+		Offset(offset).
+		Scan(&rows)
+
+	userIDs := make([]uuid.UUID, len(rows))
+	for i, row := range rows {
+		userIDs[i] = row.UserID
+	}
+
+	var users []models.User
+	if len(userIDs) > 0 {
+		database.DB.Where("id IN ?", userIDs).Find(&users)
+	}
+	usersByID := make(map[uuid.UUID]models.User, len(users))
+	for _, u := range users {
+		usersByID[u.ID] = u
+	}
+
+	isAdmin := middleware.IsAdmin(c)
+	responses := make([]fiber.Map, len(rows))
+	for i, row := range rows {
+		u := usersByID[row.UserID]
+		responses[i] = fiber.Map{
+			"rank":  row.Rank,
+			"value": row.Value,
+			"user":  u.ToResponse(isAdmin),
+		}
+	}
+
+	var me *leaderboardRow
+	if viewer := middleware.GetCurrentUser(c); viewer != nil {
+		var row leaderboardRow
+		if err := database.DB.Table("(?) AS ranked", ranked).
+			Where("user_id = ?", viewer.ID).
+			Take(&row).Error; err == nil {
+			me = &row
+		}
+	}
+
+	response := fiber.Map{
+		"leaderboard": responses,
+		"me":          me,
+		"total":       total,
+		"limit":       limit,
+		"offset":      offset,
+	}
+	for key, value := range extra {
+		response[key] = value
+	}
+
+	return c.JSON(response)
+}
+
+// GetStreakLeaderboard ranks members by their current or longest weekly or
+// monthly riding streak
+func GetStreakLeaderboard(c *fiber.Ctx) error {
+	period := streak.Period(c.Query("period", string(streak.Weekly)))
+	if !period.IsValid() {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Period must be one of weekly, monthly",
+		})
+	}
+
+	scores := database.DB.Table("user_streaks").
+		Joins("JOIN users ON users.id = user_streaks.user_id AND users.deleted_at IS NULL").
+		Where("user_streaks.period = ?", period)
+
+	kind := c.Query("kind", "current")
+	switch kind {
+	case "current":
+		// Rows past their expiry are broken streaks that haven't been
+		// recomputed yet
+		scores = scores.
+			Select("user_streaks.user_id AS user_id, user_streaks.current AS value").
+			Where("user_streaks.expires_at > ?", time.Now())
+	case "longest":
+		scores = scores.Select("user_streaks.user_id AS user_id, user_streaks.longest AS value")
+	default:
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Kind must be one of current, longest",
+		})
+	}
+
+	return sendLeaderboard(c, scores, fiber.Map{
+		"period": period,
+		"kind":   kind,
+	})
+}
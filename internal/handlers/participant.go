@@ -1,6 +1,9 @@
 package handlers
 
 import (
+	"errors"
+	"fmt"
+	"strings"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
@@ -8,16 +11,38 @@ import (
 	"github.com/udacc/uda-cycling-club/internal/database"
 	"github.com/udacc/uda-cycling-club/internal/middleware"
 	"github.com/udacc/uda-cycling-club/internal/models"
+	"github.com/udacc/uda-cycling-club/internal/services"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+type RideRegistrationRequest struct {
+	GroupID *uuid.UUID `json:"group_id"`
+}
+
 type UpdateParticipantRequest struct {
-	Attended         *bool    `json:"attended"`
-	ActualDistanceKm *float64 `json:"actual_distance_km"`
-	BonusPercentage  *float64 `json:"bonus_percentage"`
-	Notes            string   `json:"notes"`
+	GroupID          *uuid.UUID `json:"group_id"`
+	Attended         *bool      `json:"attended"`
+	ActualDistanceKm *float64   `json:"actual_distance_km"`
+	BonusPercentage  *float64   `json:"bonus_percentage"`
+	Notes            string     `json:"notes"`
+	// Reason is required when changing what a participant of a completed
+	// ride is credited
+	Reason string `json:"reason"`
 }
 
+var (
+	errRideNotOpen = errors.New("ride not open for registration")
+	errRideFull    = errors.New("ride full")
+)
+
+const (
+	maxParticipantDistanceKm = 2000
+	maxBonusPercentage       = 100
+)
+
 type BulkAttendanceRequest struct {
+	GroupID      *uuid.UUID `json:"group_id"`
 	Participants []struct {
 		UserID   uuid.UUID `json:"user_id"`
 		Attended bool      `json:"attended"`
@@ -54,13 +79,80 @@ func RegisterForRide(c *fiber.Ctx) error {
 		})
 	}
 
+	if reasons := services.CheckEligibility(database.DB, &ride, user); len(reasons) > 0 {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error":   "You are not eligible for this ride",
+			"reasons": reasons,
+		})
+	}
+
+	if pending := services.PendingWaivers(database.DB, user.ID, ride.RideTypeID); len(pending) > 0 {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error":   "You must accept the required waivers before registering",
+			"waivers": pendingWaiverResponses(pending),
+		})
+	}
+
+	var req RideRegistrationRequest
+	c.BodyParser(&req)
+
 	participant := models.RideParticipant{
 		RideID:       rideID,
 		UserID:       user.ID,
+		GroupID:      req.GroupID,
 		RegisteredAt: time.Now(),
 	}
 
-	if err := database.DB.Create(&participant).Error; err != nil {
+	if ride.HasFee() {
+		participant.PaymentStatus = models.PaymentStatusPending
+		participant.PaymentDueAt = ride.PaymentDueAt(participant.RegisteredAt)
+	}
+
+	// Locking the ride serialises registrations, so concurrent requests
+	// can't take the last place twice
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&ride, "id = ?", rideID).Error; err != nil {
+			return err
+		}
+		if ride.Status != models.RideStatusPublished {
+			return errRideNotOpen
+		}
+
+		if ride.MaxParticipants != nil {
+			var count int64
+			if err := tx.Model(&models.RideParticipant{}).Where("ride_id = ?", rideID).Count(&count).Error; err != nil {
+				return err
+			}
+			if ride.IsFull(count) {
+				return errRideFull
+			}
+		}
+
+		if participant.GroupID != nil {
+			if err := lockOpenGroup(tx, rideID, *participant.GroupID); err != nil {
+				return err
+			}
+		}
+
+		return tx.Create(&participant).Error
+	})
+	var groupErr groupUnavailableError
+	if errors.As(err, &groupErr) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": string(groupErr),
+		})
+	}
+	if errors.Is(err, errRideNotOpen) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Can only register for published rides",
+		})
+	}
+	if errors.Is(err, errRideFull) {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": "Ride is full",
+		})
+	}
+	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "Failed to register for ride",
 		})
@@ -94,8 +186,24 @@ func UnregisterFromRide(c *fiber.Ctx) error {
 		})
 	}
 
-	result := database.DB.Where("ride_id = ? AND user_id = ?", rideID, user.ID).Delete(&models.RideParticipant{})
+	// Paid registrations are kept so the payment isn't lost; staff refund
+	// them first
+	result := database.DB.
+		Where("ride_id = ? AND user_id = ? AND payment_status <> ?", rideID, user.ID, models.PaymentStatusPaid).
+		Delete(&models.RideParticipant{})
+	if result.Error != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to unregister from ride",
+		})
+	}
 	if result.RowsAffected == 0 {
+		var count int64
+		database.DB.Model(&models.RideParticipant{}).Where("ride_id = ? AND user_id = ?", rideID, user.ID).Count(&count)
+		if count > 0 {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+				"error": "You have paid for this ride. Ask the ride staff for a refund before unregistering",
+			})
+		}
 		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
 			"error": "Not registered for this ride",
 		})
@@ -114,10 +222,22 @@ func ListParticipants(c *fiber.Ctx) error {
 		})
 	}
 
+	query := database.DB.Where("ride_id = ?", rideID)
+	if groupID := c.Query("group_id"); groupID == "none" {
+		query = query.Where("group_id IS NULL")
+	} else if groupID != "" {
+		id, err := uuid.Parse(groupID)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid group ID",
+			})
+		}
+		query = query.Where("group_id = ?", id)
+	}
+
 	var participants []models.RideParticipant
-	database.DB.
+	query.
 		Preload("User").
-		Where("ride_id = ?", rideID).
 		Order("registered_at").
 		Find(&participants)
 
@@ -133,6 +253,56 @@ func ListParticipants(c *fiber.Ctx) error {
 	})
 }
 
+// ListEmergencyContacts shows ride staff the emergency contacts of everyone
+// registered for the ride
+func ListEmergencyContacts(c *fiber.Ctx) error {
+	user := middleware.GetCurrentUser(c)
+
+	rideID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid ride ID",
+		})
+	}
+
+	var ride models.Ride
+	if err := database.DB.First(&ride, "id = ?", rideID).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Ride not found",
+		})
+	}
+
+	if !isRideStaff(&ride, user) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "Only ride staff can view emergency contacts",
+		})
+	}
+
+	var participants []models.RideParticipant
+	database.DB.
+		Preload("User").
+		Where("ride_id = ?", rideID).
+		Order("registered_at").
+		Find(&participants)
+
+	// Only what's needed to identify the rider and call their contact, not
+	// their profile
+	contacts := make([]fiber.Map, len(participants))
+	for i, p := range participants {
+		lastName, firstName := p.User.GetDisplayName(user.IsAdmin)
+		contacts[i] = fiber.Map{
+			"user_id":           p.UserID,
+			"last_name":         lastName,
+			"first_name":        firstName,
+			"emergency_contact": p.User.EmergencyContact,
+		}
+	}
+
+	return c.JSON(fiber.Map{
+		"contacts": contacts,
+	})
+}
+
 func UpdateParticipant(c *fiber.Ctx) error {
 	user := middleware.GetCurrentUser(c)
 
@@ -157,12 +327,6 @@ func UpdateParticipant(c *fiber.Ctx) error {
 		})
 	}
 
-	if ride.CreatedByID != user.ID && (ride.LeaderID == nil || *ride.LeaderID != user.ID) && !user.IsAdmin {
-		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
-			"error": "Only ride creator or leader can update participants",
-		})
-	}
-
 	var participant models.RideParticipant
 	if err := database.DB.Where("id = ? AND ride_id = ?", participantID, rideID).First(&participant).Error; err != nil {
 		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
@@ -170,6 +334,13 @@ func UpdateParticipant(c *fiber.Ctx) error {
 		})
 	}
 
+	isStaff := isRideStaff(&ride, user)
+	if !isStaff && !isGroupLeaderOf(&participant, user) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "Only ride staff or the participant's group leader can update participants",
+		})
+	}
+
 	var req UpdateParticipantRequest
 	if err := c.BodyParser(&req); err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
@@ -177,22 +348,103 @@ func UpdateParticipant(c *fiber.Ctx) error {
 		})
 	}
 
-	if req.Attended != nil {
-		participant.Attended = *req.Attended
-	}
-	if req.ActualDistanceKm != nil {
-		participant.ActualDistanceKm = req.ActualDistanceKm
-	}
-	if req.BonusPercentage != nil {
-		participant.BonusPercentage = req.BonusPercentage
+	if req.ActualDistanceKm != nil && (*req.ActualDistanceKm < 0 || *req.ActualDistanceKm > maxParticipantDistanceKm) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": fmt.Sprintf("Actual distance must be between 0 and %d km", maxParticipantDistanceKm),
+		})
 	}
-	if req.Notes != "" {
-		participant.Notes = req.Notes
+	if req.BonusPercentage != nil && (*req.BonusPercentage < 0 || *req.BonusPercentage > maxBonusPercentage) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": fmt.Sprintf("Bonus percentage must be between 0 and %d", maxBonusPercentage),
+		})
 	}
 
-	participant.CalculateFinalDistance(ride.DistanceKm, ride.BonusPercentage)
-
-	if err := database.DB.Save(&participant).Error; err != nil {
+	// On a completed ride these change the member's totals, so they go
+	// through the same audited admin path as reopening
+	changesCredit := ride.Status == models.RideStatusCompleted &&
+		(req.Attended != nil || req.ActualDistanceKm != nil || req.BonusPercentage != nil)
+	req.Reason = strings.TrimSpace(req.Reason)
+	if changesCredit {
+		if !user.IsAdmin {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"error": "Only admins can change attendance or distance on a completed ride",
+			})
+		}
+		if req.Reason == "" {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "A reason is required to change a completed ride's credits",
+			})
+		}
+	}
+
+	if req.GroupID != nil && (participant.GroupID == nil || *participant.GroupID != *req.GroupID) {
+		if !isStaff {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"error": "Only ride staff can move participants between groups",
+			})
+		}
+	}
+
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		// Credits are corrected against the locked row so concurrent edits
+		// can't both apply their difference
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			First(&participant, "id = ?", participant.ID).Error; err != nil {
+			return err
+		}
+		before := participant
+
+		if req.GroupID != nil {
+			if before.GroupID == nil || *before.GroupID != *req.GroupID {
+				if err := lockOpenGroup(tx, rideID, *req.GroupID); err != nil {
+					return err
+				}
+			}
+			participant.GroupID = req.GroupID
+		}
+		if req.Attended != nil {
+			participant.Attended = *req.Attended
+		}
+		if req.ActualDistanceKm != nil {
+			participant.ActualDistanceKm = req.ActualDistanceKm
+		}
+		if req.BonusPercentage != nil {
+			participant.BonusPercentage = req.BonusPercentage
+		}
+		if req.Notes != "" {
+			participant.Notes = req.Notes
+		}
+
+		participant.CalculateFinalDistance(ride.DistanceKm, ride.BonusPercentage)
+
+		if changesCredit {
+			if err := services.CorrectParticipantCredit(tx, &ride, before, &participant, user.ID, req.Reason); err != nil {
+				return err
+			}
+		}
+		if err := tx.Omit(clause.Associations).Save(&participant).Error; err != nil {
+			return err
+		}
+		if participant.Completed != before.Completed {
+			if err := services.UpdateStreaks(tx, []uuid.UUID{participant.UserID}, time.Now()); err != nil {
+				return err
+			}
+		}
+		// A late attendance or distance correction may earn a badge
+		if changesCredit && participant.Completed {
+			if _, err := services.EvaluateBadges(tx, &ride, []uuid.UUID{participant.UserID}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	var groupErr groupUnavailableError
+	if errors.As(err, &groupErr) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": string(groupErr),
+		})
+	}
+	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "Failed to update participant",
 		})
@@ -227,12 +479,6 @@ func MarkAttendance(c *fiber.Ctx) error {
 		})
 	}
 
-	if ride.CreatedByID != user.ID && (ride.LeaderID == nil || *ride.LeaderID != user.ID) && !user.IsAdmin {
-		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
-			"error": "Only ride creator or leader can mark attendance",
-		})
-	}
-
 	if ride.Status != models.RideStatusOngoing {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 			"error": "Can only mark attendance for ongoing rides",
@@ -246,6 +492,12 @@ func MarkAttendance(c *fiber.Ctx) error {
 		})
 	}
 
+	if !isRideStaff(&ride, user) && !isGroupLeaderOf(&participant, user) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "Only ride staff or the participant's group leader can mark attendance",
+		})
+	}
+
 	participant.Attended = true
 
 	if err := database.DB.Save(&participant).Error; err != nil {
@@ -276,10 +528,15 @@ func BulkAttendance(c *fiber.Ctx) error {
 		})
 	}
 
-	if ride.CreatedByID != user.ID && (ride.LeaderID == nil || *ride.LeaderID != user.ID) && !user.IsAdmin {
-		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
-			"error": "Only ride creator or leader can mark attendance",
-		})
+	// Group leaders who aren't ride staff may only mark their own groups
+	var groupIDs []uuid.UUID
+	if !isRideStaff(&ride, user) {
+		groupIDs = ledGroupIDs(rideID, user.ID)
+		if len(groupIDs) == 0 {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"error": "Only ride staff or group leaders can mark attendance",
+			})
+		}
 	}
 
 	if ride.Status != models.RideStatusOngoing {
@@ -295,14 +552,30 @@ func BulkAttendance(c *fiber.Ctx) error {
 		})
 	}
 
+	if req.GroupID != nil {
+		groupIDs = []uuid.UUID{*req.GroupID}
+		if !isRideStaff(&ride, user) && !containsUUID(ledGroupIDs(rideID, user.ID), *req.GroupID) {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"error": "You can only mark attendance for groups you lead",
+			})
+		}
+	}
+
 	for _, p := range req.Participants {
-		database.DB.Model(&models.RideParticipant{}).
-			Where("ride_id = ? AND user_id = ?", rideID, p.UserID).
-			Update("attended", p.Attended)
+		query := database.DB.Model(&models.RideParticipant{}).
+			Where("ride_id = ? AND user_id = ?", rideID, p.UserID)
+		if groupIDs != nil {
+			query = query.Where("group_id IN ?", groupIDs)
+		}
+		query.Update("attended", p.Attended)
 	}
 
 	var participants []models.RideParticipant
-	database.DB.Preload("User").Where("ride_id = ?", rideID).Find(&participants)
+	query := database.DB.Preload("User").Where("ride_id = ?", rideID)
+	if groupIDs != nil {
+		query = query.Where("group_id IN ?", groupIDs)
+	}
+	query.Find(&participants)
 
 	isAdmin := middleware.IsAdmin(c)
 	responses := make([]models.ParticipantResponse, len(participants))
@@ -315,3 +588,12 @@ func BulkAttendance(c *fiber.Ctx) error {
 		"participants": responses,
 	})
 }
+
+func containsUUID(ids []uuid.UUID, id uuid.UUID) bool {
+	for _, candidate := range ids {
+		if candidate == id {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,336 @@
+package handlers
+
+import (
+	"errors"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/udacc/uda-cycling-club/internal/database"
+	"github.com/udacc/uda-cycling-club/internal/middleware"
+	"github.com/udacc/uda-cycling-club/internal/models"
+	"github.com/udacc/uda-cycling-club/internal/services"
+	"github.com/udacc/uda-cycling-club/pkg/payment"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	errNoPaymentDue = errors.New("no payment due")
+	errChargeFailed = errors.New("charge failed")
+
+	errPartialOnlineRefund = errors.New("partial online refund")
+	errRefundFailed        = errors.New("refund failed")
+)
+
+type RecordPaymentRequest struct {
+	Status    models.PaymentStatus `json:"status"`
+	Amount    *float64             `json:"amount"`
+	Method    models.PaymentMethod `json:"method"`
+	Reference string               `json:"reference"`
+}
+
+// ListPayments shows ride staff who has paid and how much was collected
+func ListPayments(c *fiber.Ctx) error {
+	user := middleware.GetCurrentUser(c)
+
+	rideID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid ride ID",
+		})
+	}
+
+	var ride models.Ride
+	if err := database.DB.First(&ride, "id = ?", rideID).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Ride not found",
+		})
+	}
+
+	if !isRideStaff(&ride, user) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "Only ride staff can view payments",
+		})
+	}
+
+	var participants []models.RideParticipant
+	database.DB.
+		Preload("User").
+		Where("ride_id = ?", rideID).
+		Order("registered_at").
+		Find(&participants)
+
+	var collected float64
+	counts := make(map[models.PaymentStatus]int)
+	payments := make([]models.ParticipantPaymentResponse, len(participants))
+	for i, p := range participants {
+		payments[i] = p.ToPaymentResponse(user.IsAdmin)
+		counts[p.PaymentStatus]++
+		if p.PaymentStatus == models.PaymentStatusPaid {
+			collected += p.AmountPaid
+		}
+	}
+
+	return c.JSON(fiber.Map{
+		"fee_amount":   ride.FeeAmount,
+		"fee_currency": ride.FeeCurrency,
+		"expected":     ride.FeeAmount * float64(counts[models.PaymentStatusPending]+counts[models.PaymentStatusPaid]),
+		"collected":    collected,
+		"counts":       counts,
+		"payments":     payments,
+	})
+}
+
+func GetMyPayment(c *fiber.Ctx) error {
+	user := middleware.GetCurrentUser(c)
+
+	rideID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid ride ID",
+		})
+	}
+
+	var participant models.RideParticipant
+	if err := database.DB.Where("ride_id = ? AND user_id = ?", rideID, user.ID).First(&participant).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Not registered for this ride",
+		})
+	}
+
+	return c.JSON(participant.ToPaymentResponse(user.IsAdmin))
+}
+
+// PayForRide charges the ride fee through the payment provider
+func PayForRide(c *fiber.Ctx) error {
+	user := middleware.GetCurrentUser(c)
+
+	rideID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid ride ID",
+		})
+	}
+
+	var ride models.Ride
+	if err := database.DB.First(&ride, "id = ?", rideID).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Ride not found",
+		})
+	}
+
+	if ride.Status != models.RideStatusPublished && ride.Status != models.RideStatusOngoing {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "This ride is not accepting payments",
+		})
+	}
+
+	var participant models.RideParticipant
+	if err := database.DB.Where("ride_id = ? AND user_id = ?", rideID, user.ID).First(&participant).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Not registered for this ride",
+		})
+	}
+
+	if !participant.RequiresPayment() {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "No payment is due for this registration",
+		})
+	}
+
+	if services.Payments == nil {
+		return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{
+			"error": "Online payments are not available",
+		})
+	}
+
+	// The participant stays locked while the provider is called, so a
+	// second request waits and then sees the payment already made
+	var charge *payment.Charge
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&participant, "id = ?", participant.ID).Error; err != nil {
+			return err
+		}
+		if !participant.RequiresPayment() {
+			return errNoPaymentDue
+		}
+
+		var err error
+		charge, err = services.Payments.CreateCharge(c.UserContext(), payment.ChargeRequest{
+			Amount:      ride.FeeAmount,
+			Currency:    ride.FeeCurrency,
+			Description: ride.Title,
+			Reference:   participant.ID.String(),
+		})
+		if err != nil {
+			return errChargeFailed
+		}
+
+		participant.PaymentMethod = models.PaymentMethodOnline
+		participant.PaymentReference = charge.ID
+		if charge.Status == payment.ChargeStatusSucceeded {
+			now := time.Now()
+			participant.PaymentStatus = models.PaymentStatusPaid
+			participant.AmountPaid = charge.Amount
+			participant.PaidAt = &now
+		}
+
+		return tx.Save(&participant).Error
+	})
+	if errors.Is(err, errNoPaymentDue) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "No payment is due for this registration",
+		})
+	}
+	if errors.Is(err, errChargeFailed) {
+		return c.Status(fiber.StatusBadGateway).JSON(fiber.Map{
+			"error": "Payment failed",
+		})
+	}
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to record payment",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"payment":     participant.ToPaymentResponse(user.IsAdmin),
+		"payment_url": charge.PaymentURL,
+	})
+}
+
+// RecordPayment lets ride staff record a cash or transfer payment, waive
+// the fee, or mark a refund
+func RecordPayment(c *fiber.Ctx) error {
+	user := middleware.GetCurrentUser(c)
+
+	rideID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid ride ID",
+		})
+	}
+
+	participantID, err := uuid.Parse(c.Params("pid"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid participant ID",
+		})
+	}
+
+	var ride models.Ride
+	if err := database.DB.First(&ride, "id = ?", rideID).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Ride not found",
+		})
+	}
+
+	if !isRideStaff(&ride, user) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "Only ride staff can record payments",
+		})
+	}
+
+	var participant models.RideParticipant
+	if err := database.DB.Preload("User").Where("id = ? AND ride_id = ?", participantID, rideID).First(&participant).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Participant not found",
+		})
+	}
+
+	var req RecordPaymentRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	if !req.Status.IsValid() {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid payment status",
+		})
+	}
+	if req.Method != "" && !req.Method.IsValid() {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid payment method",
+		})
+	}
+	if req.Amount != nil && *req.Amount < 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Amount cannot be negative",
+		})
+	}
+
+	// The participant is locked so an online refund is only made once
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&participant, "id = ?", participant.ID).Error; err != nil {
+			return err
+		}
+
+		if req.Status == models.PaymentStatusRefunded && participant.PaymentMethod == models.PaymentMethodOnline &&
+			participant.PaymentStatus == models.PaymentStatusPaid {
+			if req.Amount != nil && *req.Amount < participant.AmountPaid {
+				return errPartialOnlineRefund
+			}
+			if services.Payments == nil {
+				return errRefundFailed
+			}
+			if _, err := services.Payments.Refund(c.UserContext(), participant.PaymentReference); err != nil {
+				return errRefundFailed
+			}
+			req.Amount = nil
+		}
+
+		participant.PaymentStatus = req.Status
+		if req.Method != "" {
+			participant.PaymentMethod = req.Method
+		}
+		if req.Reference != "" {
+			participant.PaymentReference = req.Reference
+		}
+
+		switch req.Status {
+		case models.PaymentStatusPaid:
+			participant.AmountPaid = ride.FeeAmount
+			if req.Amount != nil {
+				participant.AmountPaid = *req.Amount
+			}
+			now := time.Now()
+			participant.PaidAt = &now
+			participant.PaymentDueAt = nil
+		case models.PaymentStatusPending:
+			participant.PaymentDueAt = ride.PaymentDueAt(time.Now())
+		case models.PaymentStatusWaived, models.PaymentStatusNotRequired:
+			participant.PaymentDueAt = nil
+		case models.PaymentStatusRefunded:
+			if req.Amount != nil {
+				participant.AmountPaid -= *req.Amount
+				if participant.AmountPaid < 0 {
+					participant.AmountPaid = 0
+				}
+			} else {
+				participant.AmountPaid = 0
+			}
+		}
+
+		return tx.Omit("User").Save(&participant).Error
+	})
+	if errors.Is(err, errPartialOnlineRefund) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Online payments can only be refunded in full",
+		})
+	}
+	if errors.Is(err, errRefundFailed) {
+		return c.Status(fiber.StatusBadGateway).JSON(fiber.Map{
+			"error": "Refund failed",
+		})
+	}
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to record payment",
+		})
+	}
+
+	return c.JSON(participant.ToPaymentResponse(user.IsAdmin))
+}
@@ -0,0 +1,261 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/udacc/uda-cycling-club/internal/config"
+	"github.com/udacc/uda-cycling-club/internal/database"
+	"github.com/udacc/uda-cycling-club/internal/middleware"
+	"github.com/udacc/uda-cycling-club/internal/models"
+	"github.com/udacc/uda-cycling-club/pkg/photo"
+)
+
+// isRideParticipant reports whether the user registered for the ride
+func isRideParticipant(rideID, userID uuid.UUID) bool {
+	var count int64
+	database.DB.Model(&models.RideParticipant{}).
+		Where("ride_id = ? AND user_id = ?", rideID, userID).
+		Count(&count)
+	return count > 0
+}
+
+func ListPhotos(c *fiber.Ctx) error {
+	rideID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid ride ID",
+		})
+	}
+
+	limit, _ := strconv.Atoi(c.Query("limit", "50"))
+	offset, _ := strconv.Atoi(c.Query("offset", "0"))
+
+	var total int64
+	database.DB.Model(&models.RidePhoto{}).Where("ride_id = ?", rideID).Count(&total)
+
+	var photos []models.RidePhoto
+	database.DB.
+		Preload("UploadedBy").
+		Where("ride_id = ?", rideID).
+		Order("created_at").
+		Limit(limit).
+		Offset(offset).
+		Find(&photos)
+
+	isAdmin := middleware.IsAdmin(c)
+	responses := make([]models.PhotoResponse, len(photos))
+	for i, p := range photos {
+		responses[i] = p.ToResponse(isAdmin)
+	}
+
+	return c.JSON(fiber.Map{
+		"photos": responses,
+		"total":  total,
+		"limit":  limit,
+		"offset": offset,
+	})
+}
+
+func UploadPhoto(c *fiber.Ctx) error {
+	user := middleware.GetCurrentUser(c)
+
+	rideID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid ride ID",
+		})
+	}
+
+	var ride models.Ride
+	if err := database.DB.First(&ride, "id = ?", rideID).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Ride not found",
+		})
+	}
+
+	if !isRideParticipant(ride.ID, user.ID) && !isRideStaff(&ride, user) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "Only participants and staff of the ride can upload photos",
+		})
+	}
+
+	file, err := c.FormFile("photo")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Photo file is required",
+		})
+	}
+
+	if file.Size > config.AppConfig.MaxPhotoSize {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "File too large",
+		})
+	}
+
+	src, err := file.Open()
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Failed to read photo",
+		})
+	}
+	data, err := io.ReadAll(src)
+	src.Close()
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Failed to read photo",
+		})
+	}
+
+	processed, err := photo.Process(data, config.AppConfig.ThumbnailSize)
+	if err != nil {
+		if errors.Is(err, photo.ErrUnsupportedType) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Only JPEG and PNG images are allowed",
+			})
+		}
+		if errors.Is(err, photo.ErrTooLarge) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Photo dimensions are too large",
+			})
+		}
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Failed to process photo: " + err.Error(),
+		})
+	}
+
+	photoDir := filepath.Join(config.AppConfig.UploadDir, "photos", ride.ID.String())
+	if err := os.MkdirAll(photoDir, 0755); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to create upload directory",
+		})
+	}
+
+	photoID := uuid.New()
+	imagePath := filepath.Join(photoDir, photoID.String()+processed.Extension)
+	thumbPath := filepath.Join(photoDir, photoID.String()+"_thumb"+processed.Extension)
+
+	if err := os.WriteFile(imagePath, processed.Image, 0644); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to save file",
+		})
+	}
+	if err := os.WriteFile(thumbPath, processed.Thumbnail, 0644); err != nil {
+		os.Remove(imagePath)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to save file",
+		})
+	}
+
+	ridePhoto := models.RidePhoto{
+		ID:            photoID,
+		RideID:        ride.ID,
+		UploadedByID:  user.ID,
+		FilePath:      imagePath,
+		ThumbnailPath: thumbPath,
+		ContentType:   processed.ContentType,
+		Width:         processed.Width,
+		Height:        processed.Height,
+		SizeBytes:     int64(len(processed.Image)),
+		Caption:       c.FormValue("caption"),
+	}
+
+	if err := database.DB.Create(&ridePhoto).Error; err != nil {
+		os.Remove(imagePath)
+		os.Remove(thumbPath)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to save photo",
+		})
+	}
+
+	database.DB.Preload("UploadedBy").First(&ridePhoto, "id = ?", ridePhoto.ID)
+
+	return c.Status(fiber.StatusCreated).JSON(ridePhoto.ToResponse(user.IsAdmin))
+}
+
+// findPhoto loads the photo named in the route params. When it returns a nil
+// photo the error response has already been written.
+func findPhoto(c *fiber.Ctx) (*models.RidePhoto, error) {
+	rideID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return nil, c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid ride ID",
+		})
+	}
+
+	photoID, err := uuid.Parse(c.Params("pid"))
+	if err != nil {
+		return nil, c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid photo ID",
+		})
+	}
+
+	var ridePhoto models.RidePhoto
+	if err := database.DB.Where("id = ? AND ride_id = ?", photoID, rideID).First(&ridePhoto).Error; err != nil {
+		return nil, c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Photo not found",
+		})
+	}
+
+	return &ridePhoto, nil
+}
+
+func GetPhotoImage(c *fiber.Ctx) error {
+	ridePhoto, err := findPhoto(c)
+	if ridePhoto == nil {
+		return err
+	}
+
+	c.Set(fiber.HeaderContentType, ridePhoto.ContentType)
+	return c.SendFile(ridePhoto.FilePath)
+}
+
+func GetPhotoThumbnail(c *fiber.Ctx) error {
+	ridePhoto, err := findPhoto(c)
+	if ridePhoto == nil {
+		return err
+	}
+
+	c.Set(fiber.HeaderContentType, ridePhoto.ContentType)
+	return c.SendFile(ridePhoto.ThumbnailPath)
+}
+
+func DeletePhoto(c *fiber.Ctx) error {
+	user := middleware.GetCurrentUser(c)
+
+	ridePhoto, err := findPhoto(c)
+	if ridePhoto == nil {
+		return err
+	}
+
+	var ride models.Ride
+	if err := database.DB.First(&ride, "id = ?", ridePhoto.RideID).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Ride not found",
+		})
+	}
+
+	if ridePhoto.UploadedByID != user.ID && !isRideStaff(&ride, user) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "Only the uploader or ride staff can delete a photo",
+		})
+	}
+
+	if err := database.DB.Delete(ridePhoto).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to delete photo",
+		})
+	}
+
+	os.Remove(ridePhoto.FilePath)
+	os.Remove(ridePhoto.ThumbnailPath)
+
+	return c.JSON(fiber.Map{
+		"message": "Photo deleted successfully",
+	})
+}
@@ -0,0 +1,170 @@
+package handlers
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/udacc/uda-cycling-club/internal/database"
+	"github.com/udacc/uda-cycling-club/internal/middleware"
+	"github.com/udacc/uda-cycling-club/internal/models"
+	"github.com/udacc/uda-cycling-club/internal/services"
+)
+
+type RideReportRequest struct {
+	ActualStartAt   string                  `json:"actual_start_at"`
+	ActualFinishAt  string                  `json:"actual_finish_at"`
+	Weather         models.WeatherCondition `json:"weather"`
+	TemperatureC    *float64                `json:"temperature_c"`
+	RouteDeviations string                  `json:"route_deviations"`
+	Mechanicals     string                  `json:"mechanicals"`
+	Incidents       string                  `json:"incidents"`
+	Summary         string                  `json:"summary"`
+}
+
+// buildRideReport validates the request and fills the report from it.
+// It returns an error message, or "" if the request is valid.
+func buildRideReport(report *models.RideReport, req *RideReportRequest) string {
+	report.ActualStartAt = nil
+	if req.ActualStartAt != "" {
+		startAt, err := time.Parse(time.RFC3339, req.ActualStartAt)
+		if err != nil {
+			return "Invalid actual_start_at format. Use RFC3339 format"
+		}
+		report.ActualStartAt = &startAt
+	}
+
+	report.ActualFinishAt = nil
+	if req.ActualFinishAt != "" {
+		finishAt, err := time.Parse(time.RFC3339, req.ActualFinishAt)
+		if err != nil {
+			return "Invalid actual_finish_at format. Use RFC3339 format"
+		}
+		report.ActualFinishAt = &finishAt
+	}
+
+	if report.ActualStartAt != nil && report.ActualFinishAt != nil && !report.ActualFinishAt.After(*report.ActualStartAt) {
+		return "actual_finish_at must be after actual_start_at"
+	}
+
+	if req.Weather != "" && !req.Weather.IsValid() {
+		return "Invalid weather. Use clear, cloudy, rain, snow, windy or fog"
+	}
+
+	report.Weather = req.Weather
+	report.TemperatureC = req.TemperatureC
+	report.RouteDeviations = req.RouteDeviations
+	report.Mechanicals = req.Mechanicals
+	report.Incidents = req.Incidents
+	report.Summary = req.Summary
+	return ""
+}
+
+func GetRideReport(c *fiber.Ctx) error {
+	rideID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid ride ID",
+		})
+	}
+
+	var report models.RideReport
+	if err := database.DB.Preload("Author").Where("ride_id = ?", rideID).First(&report).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Ride report not found",
+		})
+	}
+
+	return c.JSON(report.ToResponse(middleware.IsAdmin(c)))
+}
+
+// SubmitRideReport files or replaces the report of a completed ride
+func SubmitRideReport(c *fiber.Ctx) error {
+	user := middleware.GetCurrentUser(c)
+
+	rideID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid ride ID",
+		})
+	}
+
+	var ride models.Ride
+	if err := database.DB.First(&ride, "id = ?", rideID).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Ride not found",
+		})
+	}
+
+	if !isRideLeader(&ride, user) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "Only the ride leaders can submit the ride report",
+		})
+	}
+
+	if ride.Status != models.RideStatusCompleted {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Reports can only be submitted for completed rides",
+		})
+	}
+
+	var req RideReportRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	report := models.RideReport{AuthorID: user.ID}
+	if msg := buildRideReport(&report, &req); msg != "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": msg,
+		})
+	}
+
+	if err := services.SaveRideReport(database.DB, rideID, &report); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to save ride report",
+		})
+	}
+
+	database.DB.Preload("Author").First(&report, "id = ?", report.ID)
+
+	return c.JSON(report.ToResponse(user.IsAdmin))
+}
+
+// ListRidesMissingReports lists completed rides that have no report yet
+func ListRidesMissingReports(c *fiber.Ctx) error {
+	limit, _ := strconv.Atoi(c.Query("limit", "20"))
+	offset, _ := strconv.Atoi(c.Query("offset", "0"))
+
+	query := database.DB.Model(&models.Ride{}).
+		Where("status = ?", models.RideStatusCompleted).
+		Where("NOT EXISTS (SELECT 1 FROM ride_reports WHERE ride_reports.ride_id = rides.id)")
+
+	var total int64
+	query.Count(&total)
+
+	var rides []models.Ride
+	query.
+		Preload("RideType").
+		Preload("CreatedBy").
+		Preload("Leader").
+		Order("completed_at DESC").
+		Limit(limit).
+		Offset(offset).
+		Find(&rides)
+
+	responses := make([]models.RideResponse, len(rides))
+	for i, ride := range rides {
+		responses[i] = ride.ToResponse(true)
+	}
+
+	return c.JSON(fiber.Map{
+		"rides":  responses,
+		"total":  total,
+		"limit":  limit,
+		"offset": offset,
+	})
+}
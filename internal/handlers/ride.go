@@ -1,10 +1,12 @@
 package handlers
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
@@ -13,39 +15,53 @@ import (
 	"github.com/udacc/uda-cycling-club/internal/database"
 	"github.com/udacc/uda-cycling-club/internal/middleware"
 	"github.com/udacc/uda-cycling-club/internal/models"
+	"github.com/udacc/uda-cycling-club/internal/services"
 	"github.com/udacc/uda-cycling-club/pkg/gpx"
+	"gorm.io/gorm"
 )
 
 type CreateRideRequest struct {
-	Title            string   `json:"title"`
-	Description      string   `json:"description"`
-	RideTypeID       uint     `json:"ride_type_id"`
-	DistanceKm       float64  `json:"distance_km"`
-	ElevationGain    float64  `json:"elevation_gain"`
-	MaxGradient      float64  `json:"max_gradient"`
-	MaxDescent       float64  `json:"max_descent"`
-	PassCount        int      `json:"pass_count"`
-	StartTime        string   `json:"start_time"`
-	MeetingPointName string   `json:"meeting_point_name"`
-	MeetingPointLat  *float64 `json:"meeting_point_lat"`
-	MeetingPointLng  *float64 `json:"meeting_point_lng"`
-	BonusPercentage  float64  `json:"bonus_percentage"`
+	Title                    string   `json:"title"`
+	Description              string   `json:"description"`
+	RideTypeID               uint     `json:"ride_type_id"`
+	DistanceKm               float64  `json:"distance_km"`
+	ElevationGain            float64  `json:"elevation_gain"`
+	MaxGradient              float64  `json:"max_gradient"`
+	MaxDescent               float64  `json:"max_descent"`
+	PassCount                int      `json:"pass_count"`
+	StartTime                string   `json:"start_time"`
+	EstimatedDurationMinutes *int     `json:"estimated_duration_minutes"`
+	MeetingPointName         string   `json:"meeting_point_name"`
+	MeetingPointLat          *float64 `json:"meeting_point_lat"`
+	MeetingPointLng          *float64 `json:"meeting_point_lng"`
+	CheckInRadiusMeters      *int     `json:"check_in_radius_meters"`
+	BonusPercentage          float64  `json:"bonus_percentage"`
+	FeeAmount                float64  `json:"fee_amount"`
+	FeeCurrency              string   `json:"fee_currency"`
+	PaymentWindowHours       *int     `json:"payment_window_hours"`
+	MaxParticipants          *int     `json:"max_participants"`
 }
 
 type UpdateRideRequest struct {
-	Title            string   `json:"title"`
-	Description      string   `json:"description"`
-	RideTypeID       uint     `json:"ride_type_id"`
-	DistanceKm       *float64 `json:"distance_km"`
-	ElevationGain    *float64 `json:"elevation_gain"`
-	MaxGradient      *float64 `json:"max_gradient"`
-	MaxDescent       *float64 `json:"max_descent"`
-	PassCount        *int     `json:"pass_count"`
-	StartTime        string   `json:"start_time"`
-	MeetingPointName string   `json:"meeting_point_name"`
-	MeetingPointLat  *float64 `json:"meeting_point_lat"`
-	MeetingPointLng  *float64 `json:"meeting_point_lng"`
-	BonusPercentage  *float64 `json:"bonus_percentage"`
+	Title                    string   `json:"title"`
+	Description              string   `json:"description"`
+	RideTypeID               uint     `json:"ride_type_id"`
+	DistanceKm               *float64 `json:"distance_km"`
+	ElevationGain            *float64 `json:"elevation_gain"`
+	MaxGradient              *float64 `json:"max_gradient"`
+	MaxDescent               *float64 `json:"max_descent"`
+	PassCount                *int     `json:"pass_count"`
+	StartTime                string   `json:"start_time"`
+	EstimatedDurationMinutes *int     `json:"estimated_duration_minutes"`
+	MeetingPointName         string   `json:"meeting_point_name"`
+	MeetingPointLat          *float64 `json:"meeting_point_lat"`
+	MeetingPointLng          *float64 `json:"meeting_point_lng"`
+	CheckInRadiusMeters      *int     `json:"check_in_radius_meters"`
+	BonusPercentage          *float64 `json:"bonus_percentage"`
+	FeeAmount                *float64 `json:"fee_amount"`
+	FeeCurrency              string   `json:"fee_currency"`
+	PaymentWindowHours       *int     `json:"payment_window_hours"`
+	MaxParticipants          *int     `json:"max_participants"`
 }
 
 type StartRideRequest struct {
@@ -53,54 +69,40 @@ type StartRideRequest struct {
 }
 
 type CompleteRideRequest struct {
-	BonusPercentage *float64 `json:"bonus_percentage"`
+	BonusPercentage *float64           `json:"bonus_percentage"`
+	Report          *RideReportRequest `json:"report"`
 }
 
+type ReopenRideRequest struct {
+	Reason string `json:"reason"`
+}
+
+type CloneRideRequest struct {
+	StartTime string `json:"start_time"`
+}
+
+type UpdateRideTypeRequest struct {
+	AutoStart              *bool `json:"auto_start"`
+	AutoComplete           *bool `json:"auto_complete"`
+	DefaultDurationMinutes *int  `json:"default_duration_minutes"`
+	CompletionGraceMinutes *int  `json:"completion_grace_minutes"`
+}
+
+// ListRides lists rides with optional full-text search, range filters and
+// sorting, plus per-type and per-status counts for the current filters
 func ListRides(c *fiber.Ctx) error {
 	limit, _ := strconv.Atoi(c.Query("limit", "20"))
 	offset, _ := strconv.Atoi(c.Query("offset", "0"))
-	status := c.Query("status")
-	rideTypeID := c.Query("ride_type_id")
-
-	query := database.DB.Model(&models.Ride{})
 
 	// Get current user if authenticated
 	user := middleware.GetCurrentUser(c)
 	isAdmin := middleware.IsAdmin(c)
 
-	// Filter draft rides - only show to creator or admin
-	if status == "" {
-		// When no status filter, exclude drafts unless user is admin or creator
-		if user != nil && isAdmin {
-			// Admin sees all
-		} else if user != nil {
-			// Regular user sees non-draft + own drafts
-			query = query.Where("status != ? OR created_by_id = ?", models.RideStatusDraft, user.ID)
-		} else {
-			// Anonymous user sees only non-draft
-			query = query.Where("status != ?", models.RideStatusDraft)
-		}
-	} else if status == "draft" {
-		// Explicit draft filter - only for admin or creator
-		if user != nil && isAdmin {
-			query = query.Where("status = ?", status)
-		} else if user != nil {
-			query = query.Where("status = ? AND created_by_id = ?", status, user.ID)
-		} else {
-			// Anonymous can't see drafts
-			return c.JSON(fiber.Map{
-				"rides":  []models.RideResponse{},
-				"total":  0,
-				"limit":  limit,
-				"offset": offset,
-			})
-		}
-	} else {
-		query = query.Where("status = ?", status)
-	}
-
-	if rideTypeID != "" {
-		query = query.Where("ride_type_id = ?", rideTypeID)
+	query, msg := rideSearchQuery(c, database.DB, user, isAdmin, "")
+	if msg != "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": msg,
+		})
 	}
 
 	var total int64
@@ -112,7 +114,7 @@ func ListRides(c *fiber.Ctx) error {
 		Preload("CreatedBy").
 		Preload("Leader").
 		Preload("Participants").
-		Order("start_time DESC").
+		Order(rideSearchOrder(c)).
 		Limit(limit).
 		Offset(offset).
 		Find(&rides)
@@ -127,6 +129,7 @@ func ListRides(c *fiber.Ctx) error {
 		"total":  total,
 		"limit":  limit,
 		"offset": offset,
+		"facets": rideSearchFacets(c, database.DB, user, isAdmin),
 	})
 }
 
@@ -153,6 +156,14 @@ func GetRide(c *fiber.Ctx) error {
 		Preload("Leader").
 		Preload("Participants").
 		Preload("Participants.User").
+		Preload("Staff").
+		Preload("Staff.User").
+		Preload("Groups", func(db *gorm.DB) *gorm.DB {
+			return db.Order("sort_order")
+		}).
+		Preload("Groups.Leader").
+		Preload("Report").
+		Preload("Report.Author").
 		First(&ride, "id = ?", id).Error; err != nil {
 		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
 			"error": "Ride not found",
@@ -185,21 +196,38 @@ func CreateRide(c *fiber.Ctx) error {
 		})
 	}
 
+	if req.FeeAmount < 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "fee_amount cannot be negative",
+		})
+	}
+	if req.MaxParticipants != nil && *req.MaxParticipants <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "max_participants must be positive",
+		})
+	}
+
 	ride := models.Ride{
-		Title:            req.Title,
-		Description:      req.Description,
-		RideTypeID:       req.RideTypeID,
-		CreatedByID:      user.ID,
-		DistanceKm:       req.DistanceKm,
-		ElevationGain:    req.ElevationGain,
-		MaxGradient:      req.MaxGradient,
-		MaxDescent:       req.MaxDescent,
-		PassCount:        req.PassCount,
-		MeetingPointName: req.MeetingPointName,
-		MeetingPointLat:  req.MeetingPointLat,
-		MeetingPointLng:  req.MeetingPointLng,
-		BonusPercentage:  req.BonusPercentage,
-		Status:           models.RideStatusDraft,
+		Title:                    req.Title,
+		Description:              req.Description,
+		RideTypeID:               req.RideTypeID,
+		CreatedByID:              user.ID,
+		DistanceKm:               req.DistanceKm,
+		ElevationGain:            req.ElevationGain,
+		MaxGradient:              req.MaxGradient,
+		MaxDescent:               req.MaxDescent,
+		PassCount:                req.PassCount,
+		EstimatedDurationMinutes: req.EstimatedDurationMinutes,
+		MeetingPointName:         req.MeetingPointName,
+		MeetingPointLat:          req.MeetingPointLat,
+		MeetingPointLng:          req.MeetingPointLng,
+		CheckInRadiusMeters:      req.CheckInRadiusMeters,
+		BonusPercentage:          req.BonusPercentage,
+		FeeAmount:                req.FeeAmount,
+		FeeCurrency:              req.FeeCurrency,
+		PaymentWindowHours:       req.PaymentWindowHours,
+		MaxParticipants:          req.MaxParticipants,
+		Status:                   models.RideStatusDraft,
 	}
 
 	if req.StartTime != "" {
@@ -212,6 +240,8 @@ func CreateRide(c *fiber.Ctx) error {
 		ride.StartTime = &startTime
 	}
 
+	services.ApplyDifficulty(&ride, services.DifficultyWeights(database.DB))
+
 	if err := database.DB.Create(&ride).Error; err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "Failed to create ride",
@@ -259,6 +289,8 @@ func UpdateRide(c *fiber.Ctx) error {
 		})
 	}
 
+	original := ride
+
 	if req.Title != "" {
 		ride.Title = req.Title
 	}
@@ -277,6 +309,9 @@ func UpdateRide(c *fiber.Ctx) error {
 	if req.MeetingPointLng != nil {
 		ride.MeetingPointLng = req.MeetingPointLng
 	}
+	if req.CheckInRadiusMeters != nil {
+		ride.CheckInRadiusMeters = req.CheckInRadiusMeters
+	}
 	if req.DistanceKm != nil {
 		ride.DistanceKm = *req.DistanceKm
 	}
@@ -295,6 +330,32 @@ func UpdateRide(c *fiber.Ctx) error {
 	if req.BonusPercentage != nil {
 		ride.BonusPercentage = *req.BonusPercentage
 	}
+	if req.EstimatedDurationMinutes != nil {
+		ride.EstimatedDurationMinutes = req.EstimatedDurationMinutes
+	}
+	hadFee := ride.HasFee()
+	if req.FeeAmount != nil {
+		if *req.FeeAmount < 0 {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "fee_amount cannot be negative",
+			})
+		}
+		ride.FeeAmount = *req.FeeAmount
+	}
+	if req.FeeCurrency != "" {
+		ride.FeeCurrency = req.FeeCurrency
+	}
+	if req.PaymentWindowHours != nil {
+		ride.PaymentWindowHours = req.PaymentWindowHours
+	}
+	if req.MaxParticipants != nil {
+		if *req.MaxParticipants <= 0 {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "max_participants must be positive",
+			})
+		}
+		ride.MaxParticipants = req.MaxParticipants
+	}
 	if req.StartTime != "" {
 		startTime, err := time.Parse(time.RFC3339, req.StartTime)
 		if err != nil {
@@ -305,12 +366,26 @@ func UpdateRide(c *fiber.Ctx) error {
 		ride.StartTime = &startTime
 	}
 
+	services.ApplyDifficulty(&ride, services.DifficultyWeights(database.DB))
+
+	if scheduleChanged(&original, &ride) {
+		ride.CalendarSequence++
+	}
+
 	if err := database.DB.Save(&ride).Error; err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "Failed to update ride",
 		})
 	}
 
+	if ride.HasFee() != hadFee {
+		if err := services.SyncPaymentRequirement(database.DB, &ride); err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to update ride payments",
+			})
+		}
+	}
+
 	database.DB.Preload("RideType").Preload("CreatedBy").Preload("Leader").First(&ride, "id = ?", ride.ID)
 
 	return c.JSON(ride.ToResponse(user.IsAdmin))
@@ -345,7 +420,19 @@ func DeleteRide(c *fiber.Ctx) error {
 		})
 	}
 
-	if err := database.DB.Delete(&ride).Error; err != nil {
+	// Members may already have a published ride in their calendars; keep it
+	// in the feeds as cancelled
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if ride.Status == models.RideStatusPublished {
+			ride.Status = models.RideStatusCancelled
+			ride.CalendarSequence++
+			if err := tx.Save(&ride).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Delete(&ride).Error
+	})
+	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "Failed to delete ride",
 		})
@@ -422,6 +509,9 @@ func UploadGPX(c *fiber.Ctx) error {
 	ride.MaxGradient = stats.MaxGradient
 	ride.MaxDescent = stats.MaxDescent
 	ride.PassCount = stats.PassCount
+	services.ApplyDifficulty(&ride, services.DifficultyWeights(database.DB))
+	stats.DifficultyScore = ride.DifficultyScore
+	stats.DifficultyLevel = ride.DifficultyLevel
 
 	if err := database.DB.Save(&ride).Error; err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
@@ -464,7 +554,9 @@ func PublishRide(c *fiber.Ctx) error {
 		})
 	}
 
+	now := time.Now()
 	ride.Status = models.RideStatusPublished
+	ride.PublishedAt = &now
 
 	if err := database.DB.Save(&ride).Error; err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
@@ -510,6 +602,9 @@ func StartRide(c *fiber.Ctx) error {
 	c.BodyParser(&req)
 
 	leaderID := user.ID
+	if staffLeaderID := services.StaffLeaderID(database.DB, ride.ID); staffLeaderID != nil {
+		leaderID = *staffLeaderID
+	}
 	if req.LeaderID != nil {
 		var leader models.User
 		if err := database.DB.First(&leader, "id = ?", req.LeaderID).Error; err != nil {
@@ -525,12 +620,13 @@ func StartRide(c *fiber.Ctx) error {
 		leaderID = *req.LeaderID
 	}
 
-	now := time.Now()
-	ride.Status = models.RideStatusOngoing
-	ride.LeaderID = &leaderID
-	ride.StartedAt = &now
-
-	if err := database.DB.Save(&ride).Error; err != nil {
+	err = services.StartRide(database.DB, ride.ID, leaderID)
+	if errors.Is(err, services.ErrRideNotPublished) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Only published rides can be started",
+		})
+	}
+	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "Failed to start ride",
 		})
@@ -552,7 +648,7 @@ func CompleteRide(c *fiber.Ctx) error {
 	}
 
 	var ride models.Ride
-	if err := database.DB.Preload("Participants").First(&ride, "id = ?", id).Error; err != nil {
+	if err := database.DB.First(&ride, "id = ?", id).Error; err != nil {
 		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
 			"error": "Ride not found",
 		})
@@ -564,7 +660,16 @@ func CompleteRide(c *fiber.Ctx) error {
 		})
 	}
 
-	if ride.Status != models.RideStatusOngoing {
+	idempotencyKey := strings.TrimSpace(c.Get("Idempotency-Key"))
+	if len(idempotencyKey) > 255 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Idempotency-Key must be at most 255 characters",
+		})
+	}
+
+	// A retry of a completed request is answered by the service from the
+	// stored completion, so only reject non-ongoing rides without a key
+	if ride.Status != models.RideStatusOngoing && idempotencyKey == "" {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 			"error": "Only ongoing rides can be completed",
 		})
@@ -573,33 +678,298 @@ func CompleteRide(c *fiber.Ctx) error {
 	var req CompleteRideRequest
 	c.BodyParser(&req)
 
-	if req.BonusPercentage != nil {
-		ride.BonusPercentage = *req.BonusPercentage
+	opts := services.CompleteRideOptions{
+		BonusPercentage: req.BonusPercentage,
+		IdempotencyKey:  idempotencyKey,
+		ActorID:         &user.ID,
 	}
 
-	now := time.Now()
-	ride.Status = models.RideStatusCompleted
-	ride.CompletedAt = &now
+	if req.Report != nil {
+		report := models.RideReport{AuthorID: user.ID}
+		if msg := buildRideReport(&report, req.Report); msg != "" {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": msg,
+			})
+		}
+		opts.Report = &report
+	}
 
-	if err := database.DB.Save(&ride).Error; err != nil {
+	summary, err := services.CompleteRide(database.DB, ride.ID, opts)
+	if errors.Is(err, services.ErrRideNotOngoing) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Only ongoing rides can be completed",
+		})
+	}
+	if errors.Is(err, services.ErrIdempotencyKeyConflict) {
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
+			"error": "Idempotency-Key was already used for another ride",
+		})
+	}
+	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "Failed to complete ride",
 		})
 	}
 
-	for _, participant := range ride.Participants {
-		if participant.Attended {
-			participant.Completed = true
-			participant.CalculateFinalDistance(ride.DistanceKm, ride.BonusPercentage)
-			database.DB.Save(&participant)
+	database.DB.Preload("RideType").Preload("CreatedBy").Preload("Leader").Preload("Report").Preload("Report.Author").First(&ride, "id = ?", ride.ID)
 
-			database.DB.Model(&models.User{}).
-				Where("id = ?", participant.UserID).
-				Updates(map[string]interface{}{
-					"total_distance_km": database.DB.Raw("total_distance_km + ?", participant.FinalDistanceKm),
-					"total_rides":       database.DB.Raw("total_rides + 1"),
-				})
-		}
+	return c.JSON(fiber.Map{
+		"ride":       ride.ToResponse(user.IsAdmin),
+		"completion": summary,
+	})
+}
+
+// ReopenRide returns a completed ride to ongoing, reversing the distance
+// and ride counts credited to its participants
+func ReopenRide(c *fiber.Ctx) error {
+	user := middleware.GetCurrentUser(c)
+
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid ride ID",
+		})
+	}
+
+	var req ReopenRideRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	req.Reason = strings.TrimSpace(req.Reason)
+	if req.Reason == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "A reason is required to reopen a ride",
+		})
+	}
+
+	var ride models.Ride
+	if err := database.DB.First(&ride, "id = ?", id).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Ride not found",
+		})
+	}
+
+	reversed, err := services.ReopenRide(database.DB, ride.ID, user.ID, req.Reason)
+	if errors.Is(err, services.ErrRideNotCompleted) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Only completed rides can be reopened",
+		})
+	}
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to reopen ride",
+		})
+	}
+
+	database.DB.Preload("RideType").Preload("CreatedBy").Preload("Leader").Preload("Participants").First(&ride, "id = ?", ride.ID)
+
+	return c.JSON(fiber.Map{
+		"ride":             ride.ToResponse(user.IsAdmin),
+		"reversed_credits": reversed,
+	})
+}
+
+// GetRideAuditLog lists the audited changes made to a ride
+func GetRideAuditLog(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid ride ID",
+		})
+	}
+
+	var entries []models.AuditLog
+	database.DB.
+		Preload("Actor").
+		Preload("SubjectUser").
+		Where("ride_id = ?", id).
+		Order("created_at DESC").
+		Find(&entries)
+
+	responses := make([]models.AuditLogResponse, len(entries))
+	for i, entry := range entries {
+		responses[i] = entry.ToResponse()
+	}
+
+	return c.JSON(fiber.Map{
+		"entries": responses,
+	})
+}
+
+// CancelRide calls off a published ride. Registrations are kept so riders
+// can see what they had signed up for.
+func CancelRide(c *fiber.Ctx) error {
+	user := middleware.GetCurrentUser(c)
+
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid ride ID",
+		})
+	}
+
+	var ride models.Ride
+	if err := database.DB.First(&ride, "id = ?", id).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Ride not found",
+		})
+	}
+
+	if ride.CreatedByID != user.ID && !user.IsAdmin {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "You can only cancel your own rides",
+		})
+	}
+
+	if ride.Status != models.RideStatusPublished {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Only published rides can be cancelled",
+		})
+	}
+
+	ride.Status = models.RideStatusCancelled
+	ride.CalendarSequence++
+
+	if err := database.DB.Save(&ride).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to cancel ride",
+		})
+	}
+
+	database.DB.Preload("RideType").Preload("CreatedBy").Preload("Leader").First(&ride, "id = ?", ride.ID)
+
+	return c.JSON(ride.ToResponse(user.IsAdmin))
+}
+
+// CloneRide creates a new draft ride from an existing one, sharing its GPX
+// file and route stats so the route is not parsed again
+func CloneRide(c *fiber.Ctx) error {
+	user := middleware.GetCurrentUser(c)
+
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid ride ID",
+		})
+	}
+
+	var source models.Ride
+	if err := database.DB.First(&source, "id = ?", id).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Ride not found",
+		})
+	}
+
+	if source.Status == models.RideStatusDraft && source.CreatedByID != user.ID && !user.IsAdmin {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "You can only clone your own draft rides",
+		})
+	}
+
+	var req CloneRideRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	if req.StartTime == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "start_time is required",
+		})
+	}
+
+	startTime, err := time.Parse(time.RFC3339, req.StartTime)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid start_time format. Use RFC3339 format",
+		})
+	}
+
+	ride := models.Ride{
+		Title:                    source.Title,
+		Description:              source.Description,
+		RideTypeID:               source.RideTypeID,
+		CreatedByID:              user.ID,
+		GPXFileURL:               source.GPXFileURL,
+		DistanceKm:               source.DistanceKm,
+		ElevationGain:            source.ElevationGain,
+		MaxGradient:              source.MaxGradient,
+		MaxDescent:               source.MaxDescent,
+		PassCount:                source.PassCount,
+		DifficultyScore:          source.DifficultyScore,
+		DifficultyLevel:          source.DifficultyLevel,
+		StartTime:                &startTime,
+		EstimatedDurationMinutes: source.EstimatedDurationMinutes,
+		MeetingPointName:         source.MeetingPointName,
+		MeetingPointLat:          source.MeetingPointLat,
+		MeetingPointLng:          source.MeetingPointLng,
+		CheckInRadiusMeters:      source.CheckInRadiusMeters,
+		BonusPercentage:          source.BonusPercentage,
+		FeeAmount:                source.FeeAmount,
+		FeeCurrency:              source.FeeCurrency,
+		PaymentWindowHours:       source.PaymentWindowHours,
+		MaxParticipants:          source.MaxParticipants,
+		Status:                   models.RideStatusDraft,
+	}
+
+	if err := database.DB.Create(&ride).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to clone ride",
+		})
+	}
+
+	var rule models.EligibilityRule
+	if err := database.DB.Where("ride_id = ?", source.ID).First(&rule).Error; err == nil {
+		rule.ID = 0
+		rule.RideID = &ride.ID
+		database.DB.Create(&rule)
+	}
+
+	database.DB.Preload("RideType").Preload("CreatedBy").First(&ride, "id = ?", ride.ID)
+
+	return c.Status(fiber.StatusCreated).JSON(ride.ToResponse(user.IsAdmin))
+}
+
+// ReviewRide clears the review flag the scheduler sets on auto-completed rides
+func ReviewRide(c *fiber.Ctx) error {
+	user := middleware.GetCurrentUser(c)
+
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid ride ID",
+		})
+	}
+
+	var ride models.Ride
+	if err := database.DB.First(&ride, "id = ?", id).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Ride not found",
+		})
+	}
+
+	if ride.CreatedByID != user.ID && (ride.LeaderID == nil || *ride.LeaderID != user.ID) && !user.IsAdmin {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "Only the ride creator or leader can review the ride",
+		})
+	}
+
+	if !ride.NeedsReview {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Ride does not need review",
+		})
+	}
+
+	ride.NeedsReview = false
+
+	if err := database.DB.Save(&ride).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to review ride",
+		})
 	}
 
 	database.DB.Preload("RideType").Preload("CreatedBy").Preload("Leader").First(&ride, "id = ?", ride.ID)
@@ -607,6 +977,93 @@ func CompleteRide(c *fiber.Ctx) error {
 	return c.JSON(ride.ToResponse(user.IsAdmin))
 }
 
+// UpdateRideType changes the scheduler policy of a ride type
+func UpdateRideType(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid ride type ID",
+		})
+	}
+
+	var rideType models.RideType
+	if err := database.DB.First(&rideType, id).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Ride type not found",
+		})
+	}
+
+	var req UpdateRideTypeRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	if (req.DefaultDurationMinutes != nil && *req.DefaultDurationMinutes < 0) ||
+		(req.CompletionGraceMinutes != nil && *req.CompletionGraceMinutes < 0) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Duration and grace minutes cannot be negative",
+		})
+	}
+
+	if req.AutoStart != nil {
+		rideType.AutoStart = *req.AutoStart
+	}
+	if req.AutoComplete != nil {
+		rideType.AutoComplete = *req.AutoComplete
+	}
+	if req.DefaultDurationMinutes != nil {
+		rideType.DefaultDurationMinutes = *req.DefaultDurationMinutes
+	}
+	if req.CompletionGraceMinutes != nil {
+		rideType.CompletionGraceMinutes = *req.CompletionGraceMinutes
+	}
+	now := time.Now()
+	rideType.PolicyConfiguredAt = &now
+
+	if err := database.DB.Save(&rideType).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to update ride type",
+		})
+	}
+
+	return c.JSON(rideType)
+}
+
+// GetDifficultyWeights returns the weights used to compute ride difficulty
+func GetDifficultyWeights(c *fiber.Ctx) error {
+	return c.JSON(fiber.Map{
+		"weights": services.DifficultyWeights(database.DB),
+	})
+}
+
+// UpdateDifficultyWeights replaces the difficulty weights and rescores all rides
+func UpdateDifficultyWeights(c *fiber.Ctx) error {
+	weights := services.DifficultyWeights(database.DB)
+	if err := c.BodyParser(&weights); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	if err := weights.Validate(); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid weights: " + err.Error(),
+		})
+	}
+
+	if err := services.SaveDifficultyWeights(database.DB, weights); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to update difficulty weights",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"weights": weights,
+	})
+}
+
 // ParseGPXPreview parses a GPX file and returns route statistics without creating a ride
 func ParseGPXPreview(c *fiber.Ctx) error {
 	file, err := c.FormFile("gpx")
@@ -651,6 +1108,10 @@ func ParseGPXPreview(c *fiber.Ctx) error {
 		})
 	}
 
+	stats.DifficultyScore, stats.DifficultyLevel = gpx.CalculateDifficulty(
+		stats.DistanceKm, stats.ElevationGain, stats.MaxGradient, stats.PassCount,
+		services.DifficultyWeights(database.DB))
+
 	return c.JSON(stats)
 }
 
@@ -0,0 +1,195 @@
+package handlers
+
+import (
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/udacc/uda-cycling-club/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// rideSortOrders maps the sort query parameter to an ORDER BY clause.
+// "relevance" is handled separately since it needs the search query.
+var rideSortOrders = map[string]string{
+	"start_time_desc": "start_time DESC, id",
+	"start_time_asc":  "start_time ASC NULLS LAST, id",
+	"distance_desc":   "distance_km DESC, id",
+	"distance_asc":    "distance_km ASC, id",
+	"elevation_desc":  "elevation_gain DESC, id",
+	"elevation_asc":   "elevation_gain ASC, id",
+	"difficulty_desc": "difficulty_score DESC, id",
+	"difficulty_asc":  "difficulty_score ASC, id",
+	"created_desc":    "created_at DESC, id",
+	"published_desc":  "published_at DESC NULLS LAST, id",
+}
+
+// searchTSQuery turns free text into a prefix-matching tsquery for the
+// 'simple' configuration: "хан уул" becomes "хан:* & уул:*". Punctuation
+// is dropped so user input can't inject tsquery operators.
+func searchTSQuery(text string) string {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	terms := make([]string, len(words))
+	for i, word := range words {
+		terms[i] = word + ":*"
+	}
+	return strings.Join(terms, " & ")
+}
+
+// parseSearchTime accepts an RFC3339 timestamp or a plain date. For a plain
+// date used as an upper bound, the whole day is included.
+func parseSearchTime(value string, endOfDay bool) (time.Time, bool) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, true
+	}
+	t, err := time.ParseInLocation("2006-01-02", value, clubLocation())
+	if err != nil {
+		return time.Time{}, false
+	}
+	if endOfDay {
+		t = t.AddDate(0, 0, 1)
+	}
+	return t, true
+}
+
+// rideSearchQuery builds the filtered ride query for ListRides. The facet
+// named by skip ("status" or "ride_type") is left unfiltered so its counts
+// show what selecting another value would return. It returns an error
+// message for invalid parameters.
+func rideSearchQuery(c *fiber.Ctx, db *gorm.DB, user *models.User, isAdmin bool, skip string) (*gorm.DB, string) {
+	query := db.Model(&models.Ride{})
+
+	// Drafts are only visible to their creator and admins
+	if !isAdmin {
+		if user != nil {
+			query = query.Where("status != ? OR created_by_id = ?", models.RideStatusDraft, user.ID)
+		} else {
+			query = query.Where("status != ?", models.RideStatusDraft)
+		}
+	}
+
+	if status := c.Query("status"); status != "" && skip != "status" {
+		query = query.Where("status = ?", status)
+	}
+	if rideTypeID := c.Query("ride_type_id"); rideTypeID != "" && skip != "ride_type" {
+		query = query.Where("ride_type_id = ?", rideTypeID)
+	}
+
+	if level := c.QueryInt("difficulty_level"); level > 0 {
+		query = query.Where("difficulty_level = ?", level)
+	}
+	if minLevel := c.QueryInt("min_difficulty"); minLevel > 0 {
+		query = query.Where("difficulty_level >= ?", minLevel)
+	}
+	if maxLevel := c.QueryInt("max_difficulty"); maxLevel > 0 {
+		query = query.Where("difficulty_level <= ?", maxLevel)
+	}
+
+	if tsQuery := searchTSQuery(c.Query("q")); tsQuery != "" {
+		query = query.Where("search_vector @@ to_tsquery('simple', ?)", tsQuery)
+	}
+
+	if from := c.Query("start_from"); from != "" {
+		t, ok := parseSearchTime(from, false)
+		if !ok {
+			return nil, "Invalid start_from. Use RFC3339 or YYYY-MM-DD"
+		}
+		query = query.Where("start_time >= ?", t)
+	}
+	if to := c.Query("start_to"); to != "" {
+		t, ok := parseSearchTime(to, true)
+		if !ok {
+			return nil, "Invalid start_to. Use RFC3339 or YYYY-MM-DD"
+		}
+		query = query.Where("start_time < ?", t)
+	}
+
+	if minDistance := c.QueryFloat("min_distance"); minDistance > 0 {
+		query = query.Where("distance_km >= ?", minDistance)
+	}
+	if maxDistance := c.QueryFloat("max_distance"); maxDistance > 0 {
+		query = query.Where("distance_km <= ?", maxDistance)
+	}
+	if minElevation := c.QueryFloat("min_elevation"); minElevation > 0 {
+		query = query.Where("elevation_gain >= ?", minElevation)
+	}
+	if maxElevation := c.QueryFloat("max_elevation"); maxElevation > 0 {
+		query = query.Where("elevation_gain <= ?", maxElevation)
+	}
+
+	if leader := c.Query("leader_id"); leader != "" {
+		leaderID, err := uuid.Parse(leader)
+		if err != nil {
+			return nil, "Invalid leader_id"
+		}
+		query = query.Where(
+			"leader_id = ? OR id IN (?)", leaderID,
+			db.Model(&models.RideStaff{}).Select("ride_id").
+				Where("user_id = ? AND role IN ?", leaderID,
+					[]models.StaffRole{models.StaffRoleLeader, models.StaffRoleCoLeader}),
+		)
+	}
+
+	if c.QueryBool("has_spots") {
+		query = query.Where("max_participants IS NULL OR max_participants > (?)",
+			db.Model(&models.RideParticipant{}).Select("COUNT(*)").Where("ride_participants.ride_id = rides.id"))
+	}
+
+	return query, ""
+}
+
+// rideSearchOrder returns the ORDER BY for the sort parameter. Searches
+// default to relevance; everything else to newest start time first.
+func rideSearchOrder(c *fiber.Ctx) interface{} {
+	tsQuery := searchTSQuery(c.Query("q"))
+	sort := c.Query("sort")
+	if sort == "" && tsQuery != "" {
+		sort = "relevance"
+	}
+
+	if sort == "relevance" && tsQuery != "" {
+		return clause.OrderBy{Expression: clause.Expr{
+			SQL:  "ts_rank(search_vector, to_tsquery('simple', ?)) DESC, start_time DESC, id",
+			Vars: []interface{}{tsQuery},
+		}}
+	}
+
+	if order, ok := rideSortOrders[sort]; ok {
+		return order
+	}
+	return rideSortOrders["start_time_desc"]
+}
+
+type rideTypeFacet struct {
+	RideTypeID uint  `json:"ride_type_id"`
+	Count      int64 `json:"count"`
+}
+
+type statusFacet struct {
+	Status models.RideStatus `json:"status"`
+	Count  int64             `json:"count"`
+}
+
+// rideSearchFacets counts matching rides per ride type and per status
+func rideSearchFacets(c *fiber.Ctx, db *gorm.DB, user *models.User, isAdmin bool) fiber.Map {
+	rideTypes := []rideTypeFacet{}
+	if query, msg := rideSearchQuery(c, db, user, isAdmin, "ride_type"); msg == "" {
+		query.Select("ride_type_id, COUNT(*) AS count").Group("ride_type_id").Order("ride_type_id").Scan(&rideTypes)
+	}
+
+	statuses := []statusFacet{}
+	if query, msg := rideSearchQuery(c, db, user, isAdmin, "status"); msg == "" {
+		query.Select("status, COUNT(*) AS count").Group("status").Order("status").Scan(&statuses)
+	}
+
+	return fiber.Map{
+		"ride_types": rideTypes,
+		"statuses":   statuses,
+	}
+}
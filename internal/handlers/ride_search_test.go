@@ -0,0 +1,31 @@
+package handlers
+
+import "testing"
+
+func TestSearchTSQuery(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{name: "empty", text: "", want: ""},
+		{name: "only spaces", text: "   ", want: ""},
+		{name: "single word", text: "зайсан", want: "зайсан:*"},
+		{name: "several words", text: "хан уул", want: "хан:* & уул:*"},
+		{name: "lower-cased", text: "Богд ХАН", want: "богд:* & хан:*"},
+		{name: "extra whitespace", text: "  тэрэлж \t аялал ", want: "тэрэлж:* & аялал:*"},
+		{name: "digits kept", text: "100 km", want: "100:* & km:*"},
+		{name: "punctuation splits words", text: "хан-уул, зайсан", want: "хан:* & уул:* & зайсан:*"},
+		{name: "tsquery operators dropped", text: "a & !b | (c) <-> d:*", want: "a:* & b:* & c:* & d:*"},
+		{name: "quotes dropped", text: `'night' "ride"`, want: "night:* & ride:*"},
+		{name: "only operators", text: "&|!()", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := searchTSQuery(tt.text); got != tt.want {
+				t.Errorf("searchTSQuery(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,220 @@
+package handlers
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/udacc/uda-cycling-club/internal/database"
+	"github.com/udacc/uda-cycling-club/internal/middleware"
+	"github.com/udacc/uda-cycling-club/internal/models"
+	"github.com/udacc/uda-cycling-club/internal/services"
+)
+
+type AssignStaffRequest struct {
+	UserID uuid.UUID        `json:"user_id"`
+	Role   models.StaffRole `json:"role"`
+}
+
+// isRideStaff reports whether the user may manage the ride's participants:
+// the creator, the current leader, any assigned staff member, or an admin.
+func isRideStaff(ride *models.Ride, user *models.User) bool {
+	if user == nil {
+		return false
+	}
+	if user.IsAdmin || ride.CreatedByID == user.ID || (ride.LeaderID != nil && *ride.LeaderID == user.ID) {
+		return true
+	}
+
+	var count int64
+	database.DB.Model(&models.RideStaff{}).
+		Where("ride_id = ? AND user_id = ?", ride.ID, user.ID).
+		Count(&count)
+	return count > 0
+}
+
+// isRideLeader reports whether the user leads the ride: the creator, the
+// current leader, a staff leader or co-leader, or an admin.
+func isRideLeader(ride *models.Ride, user *models.User) bool {
+	if user == nil {
+		return false
+	}
+	if user.IsAdmin || ride.CreatedByID == user.ID || (ride.LeaderID != nil && *ride.LeaderID == user.ID) {
+		return true
+	}
+
+	var count int64
+	database.DB.Model(&models.RideStaff{}).
+		Where("ride_id = ? AND user_id = ? AND role IN ?", ride.ID, user.ID,
+			[]models.StaffRole{models.StaffRoleLeader, models.StaffRoleCoLeader}).
+		Count(&count)
+	return count > 0
+}
+
+func ListStaff(c *fiber.Ctx) error {
+	rideID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid ride ID",
+		})
+	}
+
+	var staff []models.RideStaff
+	database.DB.
+		Preload("User").
+		Where("ride_id = ?", rideID).
+		Order("created_at").
+		Find(&staff)
+
+	isAdmin := middleware.IsAdmin(c)
+	responses := make([]models.RideStaffResponse, len(staff))
+	for i, s := range staff {
+		responses[i] = s.ToResponse(isAdmin)
+	}
+
+	return c.JSON(fiber.Map{
+		"staff": responses,
+	})
+}
+
+func AssignStaff(c *fiber.Ctx) error {
+	user := middleware.GetCurrentUser(c)
+
+	rideID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid ride ID",
+		})
+	}
+
+	var ride models.Ride
+	if err := database.DB.First(&ride, "id = ?", rideID).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Ride not found",
+		})
+	}
+
+	if ride.CreatedByID != user.ID && (ride.LeaderID == nil || *ride.LeaderID != user.ID) && !user.IsAdmin {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "Only the ride creator or leader can assign staff",
+		})
+	}
+
+	if ride.Status == models.RideStatusCompleted || ride.Status == models.RideStatusCancelled {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Cannot assign staff to completed or cancelled ride",
+		})
+	}
+
+	var req AssignStaffRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	if !req.Role.IsValid() {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Role must be one of leader, co_leader, sweep, medic",
+		})
+	}
+
+	var member models.User
+	if err := database.DB.First(&member, "id = ?", req.UserID).Error; err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "User not found",
+		})
+	}
+
+	if req.Role == models.StaffRoleLeader {
+		if !member.IsRideLeader && !member.IsAdmin {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Selected user is not a ride leader",
+			})
+		}
+
+		// Assigning a new leader demotes the current one to co-leader
+		if err := services.SetRideLeader(database.DB, &ride, member.ID, user.ID); err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to assign staff",
+			})
+		}
+
+		var staff models.RideStaff
+		database.DB.Preload("User").Where("ride_id = ? AND user_id = ?", rideID, member.ID).First(&staff)
+
+		return c.Status(fiber.StatusCreated).JSON(staff.ToResponse(user.IsAdmin))
+	}
+
+	if ride.LeaderID != nil && *ride.LeaderID == member.ID && ride.Status == models.RideStatusOngoing {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Assign a new leader before changing the role of the leader of an ongoing ride",
+		})
+	}
+
+	var staff models.RideStaff
+	if err := database.DB.Where("ride_id = ? AND user_id = ?", rideID, member.ID).First(&staff).Error; err != nil {
+		staff = models.RideStaff{
+			RideID: rideID,
+			UserID: member.ID,
+		}
+	}
+	staff.Role = req.Role
+	staff.AssignedByID = &user.ID
+
+	if err := database.DB.Save(&staff).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to assign staff",
+		})
+	}
+
+	database.DB.Preload("User").First(&staff, "id = ?", staff.ID)
+
+	return c.Status(fiber.StatusCreated).JSON(staff.ToResponse(user.IsAdmin))
+}
+
+func RemoveStaff(c *fiber.Ctx) error {
+	user := middleware.GetCurrentUser(c)
+
+	rideID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid ride ID",
+		})
+	}
+
+	userID, err := uuid.Parse(c.Params("uid"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid user ID",
+		})
+	}
+
+	var ride models.Ride
+	if err := database.DB.First(&ride, "id = ?", rideID).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Ride not found",
+		})
+	}
+
+	if ride.CreatedByID != user.ID && (ride.LeaderID == nil || *ride.LeaderID != user.ID) && !user.IsAdmin {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "Only the ride creator or leader can remove staff",
+		})
+	}
+
+	if ride.LeaderID != nil && *ride.LeaderID == userID && ride.Status == models.RideStatusOngoing {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Assign a new leader before removing the leader of an ongoing ride",
+		})
+	}
+
+	result := database.DB.Where("ride_id = ? AND user_id = ?", rideID, userID).Delete(&models.RideStaff{})
+	if result.RowsAffected == 0 {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Staff member not found",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Staff member removed successfully",
+	})
+}
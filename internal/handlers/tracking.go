@@ -0,0 +1,292 @@
+package handlers
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/udacc/uda-cycling-club/internal/config"
+	"github.com/udacc/uda-cycling-club/internal/database"
+	"github.com/udacc/uda-cycling-club/internal/middleware"
+	"github.com/udacc/uda-cycling-club/internal/models"
+	"github.com/udacc/uda-cycling-club/pkg/gpx"
+)
+
+type PostPositionRequest struct {
+	Lat            *float64 `json:"lat"`
+	Lng            *float64 `json:"lng"`
+	AccuracyMeters *float64 `json:"accuracy_meters"`
+	RecordedAt     string   `json:"recorded_at"`
+}
+
+type PositionRetentionRequest struct {
+	Keep bool `json:"keep"`
+}
+
+// loadRouteProgress prepares the ride's GPX route for projecting positions,
+// or returns nil if the ride has no readable route
+func loadRouteProgress(ride *models.Ride) *gpx.RouteProgress {
+	if ride.GPXFileURL == "" {
+		return nil
+	}
+	points, err := gpx.GetRoutePoints(ride.GPXFileURL)
+	if err != nil {
+		return nil
+	}
+	return gpx.NewRouteProgress(points)
+}
+
+// livePositions returns the latest position of every rider on the ride
+func livePositions(rideID uuid.UUID, route *gpx.RouteProgress, viewerIsAdmin bool) []models.LivePosition {
+	var positions []models.RiderPosition
+	database.DB.
+		Raw(`SELECT DISTINCT ON (user_id) * FROM rider_positions
+			WHERE ride_id = ? ORDER BY user_id, recorded_at DESC`, rideID).
+		Scan(&positions)
+
+	// Raw scans skip preloads, so load the riders separately
+	userIDs := make([]uuid.UUID, len(positions))
+	for i, p := range positions {
+		userIDs[i] = p.UserID
+	}
+	var users []models.User
+	if len(userIDs) > 0 {
+		database.DB.Where("id IN ?", userIDs).Find(&users)
+	}
+	usersByID := make(map[uuid.UUID]models.User, len(users))
+	for _, u := range users {
+		usersByID[u.ID] = u
+	}
+
+	now := time.Now()
+	live := make([]models.LivePosition, 0, len(positions))
+	for _, p := range positions {
+		rider := usersByID[p.UserID]
+		entry := models.LivePosition{
+			User:               rider.ToResponse(viewerIsAdmin),
+			Lat:                p.Lat,
+			Lng:                p.Lng,
+			AccuracyMeters:     p.AccuracyMeters,
+			RecordedAt:         p.RecordedAt,
+			SecondsSinceUpdate: int(now.Sub(p.RecordedAt).Seconds()),
+		}
+		if route != nil {
+			along, off := route.DistanceAlong(p.Lat, p.Lng)
+			entry.DistanceAlongRouteKm = &along
+			entry.DistanceFromRouteKm = &off
+		}
+		live = append(live, entry)
+	}
+
+	return live
+}
+
+// PostPosition records the current user's position during an ongoing ride
+func PostPosition(c *fiber.Ctx) error {
+	user := middleware.GetCurrentUser(c)
+
+	rideID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid ride ID",
+		})
+	}
+
+	var ride models.Ride
+	if err := database.DB.First(&ride, "id = ?", rideID).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Ride not found",
+		})
+	}
+
+	if ride.Status != models.RideStatusOngoing {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Positions can only be posted for ongoing rides",
+		})
+	}
+
+	if !isRideParticipant(ride.ID, user.ID) && !isRideStaff(&ride, user) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "Only participants and staff of the ride can post positions",
+		})
+	}
+
+	var req PostPositionRequest
+	if err := c.BodyParser(&req); err != nil || req.Lat == nil || req.Lng == nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "lat and lng are required",
+		})
+	}
+
+	if *req.Lat < -90 || *req.Lat > 90 || *req.Lng < -180 || *req.Lng > 180 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid coordinates",
+		})
+	}
+
+	recordedAt := time.Now()
+	if req.RecordedAt != "" {
+		t, err := time.Parse(time.RFC3339, req.RecordedAt)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid recorded_at format. Use RFC3339 format",
+			})
+		}
+		// Clients may report a little late, but never from the future
+		if t.Before(recordedAt) {
+			recordedAt = t
+		}
+	}
+
+	position := models.RiderPosition{
+		RideID:         ride.ID,
+		UserID:         user.ID,
+		Lat:            *req.Lat,
+		Lng:            *req.Lng,
+		AccuracyMeters: req.AccuracyMeters,
+		RecordedAt:     recordedAt,
+	}
+
+	if err := database.DB.Create(&position).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to save position",
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(position)
+}
+
+// GetLivePositions returns a snapshot of every rider's latest position
+func GetLivePositions(c *fiber.Ctx) error {
+	user := middleware.GetCurrentUser(c)
+
+	rideID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid ride ID",
+		})
+	}
+
+	var ride models.Ride
+	if err := database.DB.First(&ride, "id = ?", rideID).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Ride not found",
+		})
+	}
+
+	if !isRideStaff(&ride, user) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "Only ride staff can view rider positions",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"positions": livePositions(ride.ID, loadRouteProgress(&ride), user.IsAdmin),
+	})
+}
+
+// StreamLivePositions sends rider positions as server-sent events until the
+// ride stops being ongoing or the client disconnects
+func StreamLivePositions(c *fiber.Ctx) error {
+	user := middleware.GetCurrentUser(c)
+
+	rideID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid ride ID",
+		})
+	}
+
+	var ride models.Ride
+	if err := database.DB.First(&ride, "id = ?", rideID).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Ride not found",
+		})
+	}
+
+	if !isRideStaff(&ride, user) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "Only ride staff can view rider positions",
+		})
+	}
+
+	if ride.Status != models.RideStatusOngoing {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Live positions are only available for ongoing rides",
+		})
+	}
+
+	// The fiber context is released once the handler returns, so the stream
+	// writer only captures plain values
+	route := loadRouteProgress(&ride)
+	viewerIsAdmin := user.IsAdmin
+	interval := time.Duration(config.AppConfig.LiveFeedInterval) * time.Second
+	if interval < time.Second {
+		interval = 5 * time.Second
+	}
+
+	c.Set(fiber.HeaderContentType, "text/event-stream")
+	c.Set(fiber.HeaderCacheControl, "no-cache")
+	c.Set(fiber.HeaderConnection, "keep-alive")
+
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			var status models.RideStatus
+			database.DB.Model(&models.Ride{}).Where("id = ?", rideID).Select("status").Scan(&status)
+			if status != models.RideStatusOngoing {
+				fmt.Fprintf(w, "event: ended\ndata: {\"status\":%q}\n\n", status)
+				w.Flush()
+				return
+			}
+
+			data, _ := json.Marshal(livePositions(rideID, route, viewerIsAdmin))
+			fmt.Fprintf(w, "event: positions\ndata: %s\n\n", data)
+			if err := w.Flush(); err != nil {
+				return
+			}
+
+			<-ticker.C
+		}
+	})
+
+	return nil
+}
+
+// SetPositionRetention lets a participant keep their positions after the
+// ride completes instead of having them purged
+func SetPositionRetention(c *fiber.Ctx) error {
+	user := middleware.GetCurrentUser(c)
+
+	rideID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid ride ID",
+		})
+	}
+
+	var req PositionRetentionRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	result := database.DB.Model(&models.RideParticipant{}).
+		Where("ride_id = ? AND user_id = ?", rideID, user.ID).
+		Update("keep_positions", req.Keep)
+	if result.RowsAffected == 0 {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Not registered for this ride",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"keep_positions": req.Keep,
+	})
+}
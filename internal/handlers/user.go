@@ -1,18 +1,24 @@
 package handlers
 
 import (
+	"errors"
 	"strconv"
+	"strings"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
 	"github.com/udacc/uda-cycling-club/internal/database"
 	"github.com/udacc/uda-cycling-club/internal/middleware"
 	"github.com/udacc/uda-cycling-club/internal/models"
+	"github.com/udacc/uda-cycling-club/internal/services"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type UpdateRoleRequest struct {
-	IsRideLeader *bool `json:"is_ride_leader"`
-	IsAdmin      *bool `json:"is_admin"`
+	IsRideLeader     *bool                    `json:"is_ride_leader"`
+	IsAdmin          *bool                    `json:"is_admin"`
+	MembershipStatus *models.MembershipStatus `json:"membership_status"`
 }
 
 func ListUsers(c *fiber.Ctx) error {
@@ -39,37 +45,6 @@ func ListUsers(c *fiber.Ctx) error {
 	})
 }
 
-func GetLeaderboard(c *fiber.Ctx) error {
-	limit, _ := strconv.Atoi(c.Query("limit", "20"))
-	offset, _ := strconv.Atoi(c.Query("offset", "0"))
-
-	var users []models.User
-	var total int64
-
-	database.DB.Model(&models.User{}).Where("total_distance_km > 0").Count(&total)
-	database.DB.Where("total_distance_km > 0").
-		Order("total_distance_km DESC").
-		Limit(limit).
-		Offset(offset).
-		Find(&users)
-
-	isAdmin := middleware.IsAdmin(c)
-	responses := make([]fiber.Map, len(users))
-	for i, user := range users {
-		responses[i] = fiber.Map{
-			"rank": offset + i + 1,
-			"user": user.ToResponse(isAdmin),
-		}
-	}
-
-	return c.JSON(fiber.Map{
-		"leaderboard": responses,
-		"total":       total,
-		"limit":       limit,
-		"offset":      offset,
-	})
-}
-
 func GetStatistics(c *fiber.Ctx) error {
 	var totalUsers int64
 	var totalRideLeaders int64
@@ -117,7 +92,11 @@ func GetUser(c *fiber.Ctx) error {
 	}
 
 	var user models.User
-	if err := database.DB.First(&user, "id = ?", id).Error; err != nil {
+	if err := database.DB.
+		Preload("Badges", func(db *gorm.DB) *gorm.DB { return db.Order("awarded_at DESC") }).
+		Preload("Badges.Badge").
+		Preload("Streaks").
+		First(&user, "id = ?", id).Error; err != nil {
 		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
 			"error": "User not found",
 		})
@@ -203,6 +182,14 @@ func UpdateUserRole(c *fiber.Ctx) error {
 	if req.IsAdmin != nil {
 		user.IsAdmin = *req.IsAdmin
 	}
+	if req.MembershipStatus != nil {
+		if !req.MembershipStatus.IsValid() {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Membership status must be one of active, guest, inactive",
+			})
+		}
+		user.MembershipStatus = *req.MembershipStatus
+	}
 
 	if err := database.DB.Save(&user).Error; err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
@@ -212,3 +199,134 @@ func UpdateUserRole(c *fiber.Ctx) error {
 
 	return c.JSON(user.ToResponse(true))
 }
+
+var errNegativeTotals = errors.New("adjustment makes totals negative")
+
+type CreditAdjustmentRequest struct {
+	DistanceKm float64 `json:"distance_km"`
+	RideCount  int     `json:"ride_count"`
+	Reason     string  `json:"reason"`
+}
+
+// ListUserCredits lists the ledger entries behind a user's totals. Members
+// can see their own; admins can see anyone's.
+func ListUserCredits(c *fiber.Ctx) error {
+	user := middleware.GetCurrentUser(c)
+
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid user ID",
+		})
+	}
+
+	if id != user.ID && !user.IsAdmin {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "You can only view your own credits",
+		})
+	}
+
+	limit, _ := strconv.Atoi(c.Query("limit", "50"))
+	offset, _ := strconv.Atoi(c.Query("offset", "0"))
+
+	var entries []models.CreditLedgerEntry
+	var total int64
+
+	database.DB.Model(&models.CreditLedgerEntry{}).Where("user_id = ?", id).Count(&total)
+	database.DB.
+		Preload("Ride").
+		Preload("Actor").
+		Where("user_id = ?", id).
+		Order("created_at DESC").
+		Limit(limit).
+		Offset(offset).
+		Find(&entries)
+
+	responses := make([]models.CreditLedgerEntryResponse, len(entries))
+	for i, entry := range entries {
+		responses[i] = entry.ToResponse(user.IsAdmin)
+	}
+
+	return c.JSON(fiber.Map{
+		"entries": responses,
+		"total":   total,
+		"limit":   limit,
+		"offset":  offset,
+	})
+}
+
+// AdjustUserCredits adds a manual ledger entry to a user's totals
+func AdjustUserCredits(c *fiber.Ctx) error {
+	admin := middleware.GetCurrentUser(c)
+
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid user ID",
+		})
+	}
+
+	var req CreditAdjustmentRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	req.Reason = strings.TrimSpace(req.Reason)
+	if req.Reason == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "A reason is required for manual adjustments",
+		})
+	}
+	if req.DistanceKm == 0 && req.RideCount == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Adjustment must change distance or ride count",
+		})
+	}
+
+	var user models.User
+	if err := database.DB.First(&user, "id = ?", id).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "User not found",
+		})
+	}
+
+	entry := models.CreditLedgerEntry{
+		UserID:     user.ID,
+		DistanceKm: req.DistanceKm,
+		RideCount:  req.RideCount,
+		Reason:     models.CreditReasonManualAdjustment,
+		Note:       req.Reason,
+		ActorID:    &admin.ID,
+	}
+
+	// The user is locked so concurrent adjustments are checked against each
+	// other's results
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&user, "id = ?", user.ID).Error; err != nil {
+			return err
+		}
+		if user.TotalDistanceKm+req.DistanceKm < 0 || user.TotalRides+req.RideCount < 0 {
+			return errNegativeTotals
+		}
+		return services.RecordCredit(tx, &entry)
+	})
+	if errors.Is(err, errNegativeTotals) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Adjustment cannot make the member's totals negative",
+		})
+	}
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to record adjustment",
+		})
+	}
+
+	database.DB.First(&user, "id = ?", user.ID)
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"entry": entry.ToResponse(true),
+		"user":  user.ToResponse(true),
+	})
+}
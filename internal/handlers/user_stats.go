@@ -0,0 +1,172 @@
+package handlers
+
+import (
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/udacc/uda-cycling-club/internal/database"
+	"github.com/udacc/uda-cycling-club/internal/middleware"
+	"github.com/udacc/uda-cycling-club/internal/models"
+	"github.com/udacc/uda-cycling-club/internal/services"
+	"gorm.io/gorm"
+)
+
+type periodStats struct {
+	Period        string  `json:"period"`
+	DistanceKm    float64 `json:"distance_km"`
+	Rides         int     `json:"rides"`
+	ElevationGain float64 `json:"elevation_gain"`
+}
+
+type rideTypeStats struct {
+	RideTypeID    uint    `json:"ride_type_id"`
+	Name          string  `json:"name"`
+	DistanceKm    float64 `json:"distance_km"`
+	Rides         int     `json:"rides"`
+	ElevationGain float64 `json:"elevation_gain"`
+}
+
+type longestRide struct {
+	RideID      uuid.UUID `json:"ride_id"`
+	Title       string    `json:"title"`
+	DistanceKm  float64   `json:"distance_km"`
+	CompletedAt time.Time `json:"completed_at"`
+}
+
+type favoriteLeader struct {
+	User  models.UserResponse `json:"user"`
+	Rides int                 `json:"rides"`
+}
+
+type climbingDay struct {
+	Date          string  `json:"date"`
+	ElevationGain float64 `json:"elevation_gain"`
+	Rides         int     `json:"rides"`
+}
+
+// completedRides selects the user's completed participations joined with
+// their rides
+func completedRides(userID uuid.UUID) *gorm.DB {
+	return database.DB.Table("ride_participants").
+		Joins("JOIN rides ON rides.id = ride_participants.ride_id").
+		Where("ride_participants.user_id = ? AND ride_participants.completed = ? AND rides.status = ?",
+			userID, true, models.RideStatusCompleted)
+}
+
+// periodTotals groups the user's rides by ride date formatted in
+// club time, e.g. "YYYY-MM"
+func periodTotals(userID uuid.UUID, format string) []periodStats {
+	period := "to_char(" + services.RideDate + " AT TIME ZONE ?, '" + format + "')"
+
+	stats := []periodStats{}
+	completedRides(userID).
+		Select(period+" AS period, "+
+			"SUM(ride_participants.final_distance_km) AS distance_km, "+
+			"COUNT(*) AS rides, "+
+			"SUM(rides.elevation_gain) AS elevation_gain", clubLocation().String()).
+		Group("period").
+		Order("period").
+		Scan(&stats)
+	return stats
+}
+
+// GetUserStats summarises a member's completed rides by month, year and
+// ride type, along with their records. Private members' statistics are
+// only shown to themselves and admins.
+func GetUserStats(c *fiber.Ctx) error {
+	viewer := middleware.GetCurrentUser(c)
+
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid user ID",
+		})
+	}
+
+	var user models.User
+	if err := database.DB.First(&user, "id = ?", id).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "User not found",
+		})
+	}
+
+	isAdmin := viewer != nil && viewer.IsAdmin
+	if user.IsPrivate && !isAdmin && (viewer == nil || viewer.ID != user.ID) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "This member's statistics are private",
+		})
+	}
+
+	byType := []rideTypeStats{}
+	completedRides(user.ID).
+		Select("rides.ride_type_id, ride_types.name, " +
+			"SUM(ride_participants.final_distance_km) AS distance_km, " +
+			"COUNT(*) AS rides, " +
+			"SUM(rides.elevation_gain) AS elevation_gain").
+		Joins("JOIN ride_types ON ride_types.id = rides.ride_type_id").
+		Group("rides.ride_type_id, ride_types.name").
+		Order("distance_km DESC").
+		Scan(&byType)
+
+	var longest *longestRide
+	var longestRow longestRide
+	if err := completedRides(user.ID).
+		Select("rides.id AS ride_id, rides.title, ride_participants.final_distance_km AS distance_km, rides.completed_at").
+		Order("ride_participants.final_distance_km DESC, " + services.RideDate).
+		Take(&longestRow).Error; err == nil {
+		longest = &longestRow
+	}
+
+	var biggestClimb *climbingDay
+	var climbRow climbingDay
+	if err := completedRides(user.ID).
+		Select("to_char("+services.RideDate+" AT TIME ZONE ?, 'YYYY-MM-DD') AS date, "+
+			"SUM(rides.elevation_gain) AS elevation_gain, COUNT(*) AS rides", clubLocation().String()).
+		Group("date").
+		Having("SUM(rides.elevation_gain) > 0").
+		Order("elevation_gain DESC, date").
+		Take(&climbRow).Error; err == nil {
+		biggestClimb = &climbRow
+	}
+
+	// The leader of most of the member's rides, not counting rides they led
+	var favorite *favoriteLeader
+	var leader struct {
+		LeaderID  uuid.UUID
+		RideCount int
+	}
+	if err := completedRides(user.ID).
+		Select("rides.leader_id, COUNT(*) AS ride_count").
+		Where("rides.leader_id IS NOT NULL AND rides.leader_id <> ?", user.ID).
+		Group("rides.leader_id").
+		Order("ride_count DESC, rides.leader_id").
+		Take(&leader).Error; err == nil {
+		var leaderUser models.User
+		if err := database.DB.First(&leaderUser, "id = ?", leader.LeaderID).Error; err == nil {
+			favorite = &favoriteLeader{User: leaderUser.ToResponse(isAdmin), Rides: leader.RideCount}
+		}
+	}
+
+	var span struct {
+		FirstRideAt *time.Time
+		LastRideAt  *time.Time
+	}
+	completedRides(user.ID).
+		Select("MIN(" + services.RideDate + ") AS first_ride_at, MAX(" + services.RideDate + ") AS last_ride_at").
+		Scan(&span)
+
+	return c.JSON(fiber.Map{
+		"user_id":           user.ID,
+		"total_distance_km": user.TotalDistanceKm,
+		"total_rides":       user.TotalRides,
+		"monthly":           periodTotals(user.ID, "YYYY-MM"),
+		"yearly":            periodTotals(user.ID, "YYYY"),
+		"by_ride_type":      byType,
+		"longest_ride":      longest,
+		"biggest_climb_day": biggestClimb,
+		"favorite_leader":   favorite,
+		"first_ride_at":     span.FirstRideAt,
+		"last_ride_at":      span.LastRideAt,
+	})
+}
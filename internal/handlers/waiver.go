@@ -0,0 +1,461 @@
+package handlers
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/udacc/uda-cycling-club/internal/database"
+	"github.com/udacc/uda-cycling-club/internal/middleware"
+	"github.com/udacc/uda-cycling-club/internal/models"
+	"github.com/udacc/uda-cycling-club/internal/services"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type WaiverRequest struct {
+	Slug      string `json:"slug"`
+	Title     string `json:"title"`
+	IsGeneral *bool  `json:"is_general"`
+	IsActive  *bool  `json:"is_active"`
+}
+
+type WaiverVersionRequest struct {
+	Body string `json:"body"`
+}
+
+type AcceptWaiverRequest struct {
+	Version int `json:"version"`
+}
+
+type RideTypeWaiversRequest struct {
+	WaiverIDs []uint `json:"waiver_ids"`
+}
+
+func findWaiver(c *fiber.Ctx) (*models.Waiver, error) {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return nil, c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid waiver ID",
+		})
+	}
+
+	var waiver models.Waiver
+	if err := database.DB.First(&waiver, id).Error; err != nil {
+		return nil, c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Waiver not found",
+		})
+	}
+
+	return &waiver, nil
+}
+
+func pendingWaiverResponses(pending []services.PendingWaiver) []models.WaiverResponse {
+	responses := make([]models.WaiverResponse, len(pending))
+	for i, p := range pending {
+		responses[i] = p.Waiver.ToResponse(&p.Current, false)
+	}
+	return responses
+}
+
+func ListWaivers(c *fiber.Ctx) error {
+	query := database.DB.Order("id")
+	if !middleware.IsAdmin(c) {
+		query = query.Where("is_active = ?", true)
+	}
+
+	var waivers []models.Waiver
+	query.Find(&waivers)
+
+	responses := make([]models.WaiverResponse, len(waivers))
+	for i, w := range waivers {
+		responses[i] = w.ToResponse(services.CurrentWaiverVersion(database.DB, w.ID), false)
+	}
+
+	return c.JSON(fiber.Map{
+		"waivers": responses,
+	})
+}
+
+// GetWaiver returns a waiver with the text of its current version
+func GetWaiver(c *fiber.Ctx) error {
+	waiver, err := findWaiver(c)
+	if waiver == nil {
+		return err
+	}
+
+	return c.JSON(waiver.ToResponse(services.CurrentWaiverVersion(database.DB, waiver.ID), true))
+}
+
+// GetPendingWaivers lists the waivers the current user has yet to accept,
+// either for a specific ride or, without ride_id, the general ones
+func GetPendingWaivers(c *fiber.Ctx) error {
+	user := middleware.GetCurrentUser(c)
+
+	var rideTypeID uint
+	if rideIDParam := c.Query("ride_id"); rideIDParam != "" {
+		rideID, err := uuid.Parse(rideIDParam)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid ride ID",
+			})
+		}
+
+		var ride models.Ride
+		if err := database.DB.First(&ride, "id = ?", rideID).Error; err != nil {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "Ride not found",
+			})
+		}
+		rideTypeID = ride.RideTypeID
+	}
+
+	return c.JSON(fiber.Map{
+		"waivers": pendingWaiverResponses(services.PendingWaivers(database.DB, user.ID, rideTypeID)),
+	})
+}
+
+// AcceptWaiver records the current user's acceptance of a waiver version.
+// Only the current version can be accepted.
+func AcceptWaiver(c *fiber.Ctx) error {
+	user := middleware.GetCurrentUser(c)
+
+	waiver, err := findWaiver(c)
+	if waiver == nil {
+		return err
+	}
+
+	var req AcceptWaiverRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	current := services.CurrentWaiverVersion(database.DB, waiver.ID)
+	if current == nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Waiver has not been published",
+		})
+	}
+
+	if req.Version != current.Version {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error":           "Waiver has a newer version; please review it before accepting",
+			"current_version": current.Version,
+		})
+	}
+
+	var acceptance models.WaiverAcceptance
+	if err := database.DB.Where("user_id = ? AND waiver_version_id = ?", user.ID, current.ID).First(&acceptance).Error; err == nil {
+		return c.JSON(acceptance)
+	}
+
+	userAgent := c.Get(fiber.HeaderUserAgent)
+	if len(userAgent) > 500 {
+		userAgent = userAgent[:500]
+	}
+
+	acceptance = models.WaiverAcceptance{
+		UserID:          user.ID,
+		WaiverVersionID: current.ID,
+		WaiverID:        waiver.ID,
+		Version:         current.Version,
+		IPAddress:       c.IP(),
+		UserAgent:       userAgent,
+		AcceptedAt:      time.Now(),
+	}
+
+	// A concurrent request may have recorded the same acceptance since the
+	// check above; return that one instead of failing on the unique index
+	result := database.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(&acceptance)
+	if result.Error != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to record acceptance",
+		})
+	}
+	if result.RowsAffected == 0 {
+		database.DB.Where("user_id = ? AND waiver_version_id = ?", user.ID, current.ID).First(&acceptance)
+		return c.JSON(acceptance)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(acceptance)
+}
+
+func CreateWaiver(c *fiber.Ctx) error {
+	var req WaiverRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	req.Slug = strings.ToLower(strings.TrimSpace(req.Slug))
+	req.Title = strings.TrimSpace(req.Title)
+	if req.Slug == "" || req.Title == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Slug and title are required",
+		})
+	}
+
+	var existing models.Waiver
+	if err := database.DB.Where("slug = ?", req.Slug).First(&existing).Error; err == nil {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": "A waiver with this slug already exists",
+		})
+	}
+
+	waiver := models.Waiver{
+		Slug:     req.Slug,
+		Title:    req.Title,
+		IsActive: true,
+	}
+	if req.IsGeneral != nil {
+		waiver.IsGeneral = *req.IsGeneral
+	}
+	if req.IsActive != nil {
+		waiver.IsActive = *req.IsActive
+	}
+
+	if err := database.DB.Create(&waiver).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to create waiver",
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(waiver.ToResponse(nil, false))
+}
+
+func UpdateWaiver(c *fiber.Ctx) error {
+	waiver, err := findWaiver(c)
+	if waiver == nil {
+		return err
+	}
+
+	var req WaiverRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	if title := strings.TrimSpace(req.Title); title != "" {
+		waiver.Title = title
+	}
+	if req.IsGeneral != nil {
+		waiver.IsGeneral = *req.IsGeneral
+	}
+	if req.IsActive != nil {
+		waiver.IsActive = *req.IsActive
+	}
+
+	if err := database.DB.Save(waiver).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to update waiver",
+		})
+	}
+
+	return c.JSON(waiver.ToResponse(services.CurrentWaiverVersion(database.DB, waiver.ID), false))
+}
+
+func ListWaiverVersions(c *fiber.Ctx) error {
+	waiver, err := findWaiver(c)
+	if waiver == nil {
+		return err
+	}
+
+	var versions []models.WaiverVersion
+	database.DB.Where("waiver_id = ?", waiver.ID).Order("version DESC").Find(&versions)
+
+	return c.JSON(fiber.Map{
+		"versions": versions,
+	})
+}
+
+// CreateWaiverVersion adds a draft version after the latest one
+func CreateWaiverVersion(c *fiber.Ctx) error {
+	user := middleware.GetCurrentUser(c)
+
+	waiver, err := findWaiver(c)
+	if waiver == nil {
+		return err
+	}
+
+	var req WaiverVersionRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	if strings.TrimSpace(req.Body) == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Body is required",
+		})
+	}
+
+	version := models.WaiverVersion{
+		WaiverID:    waiver.ID,
+		Body:        req.Body,
+		CreatedByID: user.ID,
+	}
+
+	// Locking the waiver serialises concurrent requests so each gets its
+	// own version number
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&models.Waiver{}, waiver.ID).Error; err != nil {
+			return err
+		}
+
+		var latest int
+		if err := tx.Model(&models.WaiverVersion{}).
+			Where("waiver_id = ?", waiver.ID).
+			Select("COALESCE(MAX(version), 0)").
+			Scan(&latest).Error; err != nil {
+			return err
+		}
+
+		version.Version = latest + 1
+		return tx.Create(&version).Error
+	})
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to create waiver version",
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(version)
+}
+
+// PublishWaiverVersion makes a draft the current version. Everyone who
+// accepted an earlier version has to accept again before registering.
+func PublishWaiverVersion(c *fiber.Ctx) error {
+	waiver, err := findWaiver(c)
+	if waiver == nil {
+		return err
+	}
+
+	versionNumber, err := strconv.Atoi(c.Params("version"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid version",
+		})
+	}
+
+	var version models.WaiverVersion
+	if err := database.DB.Where("waiver_id = ? AND version = ?", waiver.ID, versionNumber).First(&version).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Waiver version not found",
+		})
+	}
+
+	if version.PublishedAt != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Version is already published",
+		})
+	}
+
+	if current := services.CurrentWaiverVersion(database.DB, waiver.ID); current != nil && current.Version > version.Version {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "A newer version is already published",
+		})
+	}
+
+	now := time.Now()
+	version.PublishedAt = &now
+
+	if err := database.DB.Save(&version).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to publish waiver version",
+		})
+	}
+
+	return c.JSON(version)
+}
+
+func ListWaiverAcceptances(c *fiber.Ctx) error {
+	waiver, err := findWaiver(c)
+	if waiver == nil {
+		return err
+	}
+
+	limit, _ := strconv.Atoi(c.Query("limit", "50"))
+	offset, _ := strconv.Atoi(c.Query("offset", "0"))
+
+	query := database.DB.Model(&models.WaiverAcceptance{}).Where("waiver_id = ?", waiver.ID)
+	if version := c.QueryInt("version"); version > 0 {
+		query = query.Where("version = ?", version)
+	}
+
+	var total int64
+	query.Count(&total)
+
+	var acceptances []models.WaiverAcceptance
+	query.Order("accepted_at DESC").Limit(limit).Offset(offset).Find(&acceptances)
+
+	return c.JSON(fiber.Map{
+		"acceptances": acceptances,
+		"total":       total,
+		"limit":       limit,
+		"offset":      offset,
+	})
+}
+
+// SetRideTypeWaivers replaces the waivers a ride type requires on top of the
+// general ones
+func SetRideTypeWaivers(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid ride type ID",
+		})
+	}
+
+	var rideType models.RideType
+	if err := database.DB.First(&rideType, id).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Ride type not found",
+		})
+	}
+
+	var req RideTypeWaiversRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	if len(req.WaiverIDs) > 0 {
+		var count int64
+		database.DB.Model(&models.Waiver{}).Where("id IN ?", req.WaiverIDs).Count(&count)
+		if int(count) != len(req.WaiverIDs) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Unknown waiver ID",
+			})
+		}
+	}
+
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("ride_type_id = ?", rideType.ID).Delete(&models.RideTypeWaiver{}).Error; err != nil {
+			return err
+		}
+		for _, waiverID := range req.WaiverIDs {
+			if err := tx.Create(&models.RideTypeWaiver{RideTypeID: rideType.ID, WaiverID: waiverID}).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to update ride type waivers",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"ride_type_id": rideType.ID,
+		"waiver_ids":   req.WaiverIDs,
+	})
+}
@@ -129,6 +129,32 @@ func AuthRequired() fiber.Handler {
 	}
 }
 
+// OptionalAuth loads the current user when a valid access token is sent but,
+// unlike AuthRequired, lets anonymous requests through.
+func OptionalAuth() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		parts := strings.Split(c.Get("Authorization"), " ")
+		if len(parts) != 2 || parts[0] != "Bearer" {
+			return c.Next()
+		}
+
+		claims, err := ParseToken(parts[1])
+		if err != nil || claims.TokenType != AccessToken {
+			return c.Next()
+		}
+
+		var user models.User
+		if err := database.DB.First(&user, "id = ?", claims.UserID).Error; err != nil {
+			return c.Next()
+		}
+
+		c.Locals("user", &user)
+		c.Locals("claims", claims)
+
+		return c.Next()
+	}
+}
+
 func RideLeaderRequired() fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		user := c.Locals("user").(*models.User)
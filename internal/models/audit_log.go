@@ -0,0 +1,68 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type AuditAction string
+
+const (
+	AuditActionRideReopened    AuditAction = "ride.reopened"
+	AuditActionCreditReversed  AuditAction = "ride.credit_reversed"
+	AuditActionCreditCorrected AuditAction = "ride.credit_corrected"
+)
+
+// AuditLog records administrative changes to rides and member totals.
+// DistanceKm and RideCount hold the change applied to the subject user's
+// totals, if any.
+type AuditLog struct {
+	ID            uuid.UUID   `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	Action        AuditAction `gorm:"size:50;not null;index" json:"action"`
+	ActorID       *uuid.UUID  `gorm:"type:uuid;index" json:"actor_id"`
+	Actor         *User       `gorm:"foreignKey:ActorID" json:"-"`
+	RideID        *uuid.UUID  `gorm:"type:uuid;index" json:"ride_id"`
+	SubjectUserID *uuid.UUID  `gorm:"type:uuid;index" json:"subject_user_id"`
+	SubjectUser   *User       `gorm:"foreignKey:SubjectUserID" json:"-"`
+	DistanceKm    float64     `gorm:"type:decimal(10,2);default:0" json:"distance_km"`
+	RideCount     int         `gorm:"default:0" json:"ride_count"`
+	Reason        string      `gorm:"type:text" json:"reason"`
+	CreatedAt     time.Time   `gorm:"index" json:"created_at"`
+}
+
+type AuditLogResponse struct {
+	ID          uuid.UUID     `json:"id"`
+	Action      AuditAction   `json:"action"`
+	Actor       *UserResponse `json:"actor,omitempty"`
+	RideID      *uuid.UUID    `json:"ride_id"`
+	SubjectUser *UserResponse `json:"subject_user,omitempty"`
+	DistanceKm  float64       `json:"distance_km"`
+	RideCount   int           `json:"ride_count"`
+	Reason      string        `json:"reason"`
+	CreatedAt   time.Time     `json:"created_at"`
+}
+
+func (a *AuditLog) ToResponse() AuditLogResponse {
+	resp := AuditLogResponse{
+		ID:         a.ID,
+		Action:     a.Action,
+		RideID:     a.RideID,
+		DistanceKm: a.DistanceKm,
+		RideCount:  a.RideCount,
+		Reason:     a.Reason,
+		CreatedAt:  a.CreatedAt,
+	}
+
+	if a.Actor != nil && a.Actor.ID != uuid.Nil {
+		actorResp := a.Actor.ToResponse(true)
+		resp.Actor = &actorResp
+	}
+
+	if a.SubjectUser != nil && a.SubjectUser.ID != uuid.Nil {
+		subjectResp := a.SubjectUser.ToResponse(true)
+		resp.SubjectUser = &subjectResp
+	}
+
+	return resp
+}
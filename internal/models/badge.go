@@ -0,0 +1,118 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// BadgeRuleType is what a badge measures. Threshold is compared against it.
+type BadgeRuleType string
+
+const (
+	// Distance credited for a single ride
+	BadgeRuleSingleRideDistance BadgeRuleType = "single_ride_distance"
+	// Total credited distance
+	BadgeRuleTotalDistance BadgeRuleType = "total_distance"
+	// Number of completed rides
+	BadgeRuleRideCount BadgeRuleType = "ride_count"
+	// Total passes of completed rides
+	BadgeRulePassesClimbed BadgeRuleType = "passes_climbed"
+	// Number of completed rides led
+	BadgeRuleRidesLed BadgeRuleType = "rides_led"
+	// Distinct ride types completed; a zero threshold means every type
+	BadgeRuleRideTypesCompleted BadgeRuleType = "ride_types_completed"
+)
+
+func (t BadgeRuleType) IsValid() bool {
+	switch t {
+	case BadgeRuleSingleRideDistance, BadgeRuleTotalDistance, BadgeRuleRideCount,
+		BadgeRulePassesClimbed, BadgeRuleRidesLed, BadgeRuleRideTypesCompleted:
+		return true
+	}
+	return false
+}
+
+type BadgeScope string
+
+const (
+	BadgeScopeAllTime BadgeScope = "all_time"
+	// Counted within one riding season and awarded again each season
+	BadgeScopeSeason BadgeScope = "season"
+)
+
+func (s BadgeScope) IsValid() bool {
+	return s == BadgeScopeAllTime || s == BadgeScopeSeason
+}
+
+// Badge is an achievement defined by a rule. RideTypeID, if set, limits the
+// rule to rides of that type.
+type Badge struct {
+	ID          uint          `gorm:"primaryKey" json:"id"`
+	Slug        string        `gorm:"uniqueIndex;size:100;not null" json:"slug"`
+	Name        string        `gorm:"size:200;not null" json:"name"`
+	Description string        `gorm:"type:text" json:"description"`
+	IconURL     string        `gorm:"size:500" json:"icon_url"`
+	RuleType    BadgeRuleType `gorm:"size:30;not null" json:"rule_type"`
+	Threshold   float64       `gorm:"type:decimal(10,2);default:0" json:"threshold"`
+	Scope       BadgeScope    `gorm:"size:20;default:'all_time'" json:"scope"`
+	RideTypeID  *uint         `json:"ride_type_id"`
+	IsActive    bool          `json:"is_active"`
+	CreatedAt   time.Time     `json:"created_at"`
+	UpdatedAt   time.Time     `json:"updated_at"`
+}
+
+// UserBadge is a badge awarded to a user. Season badges carry the season
+// they were earned in, e.g. "2026"; all-time badges leave it empty.
+type UserBadge struct {
+	ID      uuid.UUID  `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	UserID  uuid.UUID  `gorm:"type:uuid;not null;uniqueIndex:idx_user_badge_season" json:"user_id"`
+	BadgeID uint       `gorm:"not null;uniqueIndex:idx_user_badge_season" json:"badge_id"`
+	Badge   Badge      `gorm:"foreignKey:BadgeID" json:"badge"`
+	Season  string     `gorm:"size:20;not null;default:'';uniqueIndex:idx_user_badge_season" json:"season"`
+	RideID  *uuid.UUID `gorm:"type:uuid;index" json:"ride_id"`
+	// CompletionID is the ride completion that awarded the badge, if any
+	CompletionID *uuid.UUID `gorm:"type:uuid;index" json:"completion_id"`
+	AwardedAt    time.Time  `json:"awarded_at"`
+}
+
+type UserBadgeResponse struct {
+	ID          uuid.UUID  `json:"id"`
+	UserID      uuid.UUID  `json:"user_id"`
+	BadgeID     uint       `json:"badge_id"`
+	Slug        string     `json:"slug"`
+	Name        string     `json:"name"`
+	Description string     `json:"description"`
+	IconURL     string     `json:"icon_url"`
+	Season      string     `json:"season,omitempty"`
+	RideID      *uuid.UUID `json:"ride_id"`
+	AwardedAt   time.Time  `json:"awarded_at"`
+}
+
+func (ub *UserBadge) ToResponse() UserBadgeResponse {
+	return UserBadgeResponse{
+		ID:          ub.ID,
+		UserID:      ub.UserID,
+		BadgeID:     ub.BadgeID,
+		Slug:        ub.Badge.Slug,
+		Name:        ub.Badge.Name,
+		Description: ub.Badge.Description,
+		IconURL:     ub.Badge.IconURL,
+		Season:      ub.Season,
+		RideID:      ub.RideID,
+		AwardedAt:   ub.AwardedAt,
+	}
+}
+
+var DefaultBadges = []Badge{
+	{Slug: "first-century", Name: "Эхний 100 км", Description: "Нэг жийлтээр 100 км туулсан",
+		RuleType: BadgeRuleSingleRideDistance, Threshold: 100, Scope: BadgeScopeAllTime, IsActive: true},
+	{Slug: "season-1000", Name: "Улирлын 1000 км", Description: "Нэг улиралд 1000 км туулсан",
+		RuleType: BadgeRuleTotalDistance, Threshold: 1000, Scope: BadgeScopeSeason, IsActive: true},
+	{Slug: "ten-passes", Name: "10 даваа", Description: "Нийт 10 даваа давсан",
+		RuleType: BadgeRulePassesClimbed, Threshold: 10, Scope: BadgeScopeAllTime, IsActive: true},
+	{Slug: "leader-20", Name: "20 жийлт удирдсан", Description: "20 жийлтийг ахлан удирдсан",
+		RuleType: BadgeRuleRidesLed, Threshold: 20, Scope: BadgeScopeAllTime, IsActive: true},
+	{Slug: "all-ride-types", Name: "Бүх төрөл", Description: "Бүх төрлийн жийлтэд оролцсон",
+		RuleType: BadgeRuleRideTypesCompleted, Threshold: 0, Scope: BadgeScopeAllTime, IsActive: true},
+}
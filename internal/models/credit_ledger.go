@@ -0,0 +1,73 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type CreditReason string
+
+const (
+	CreditReasonRideCompleted        CreditReason = "ride_completed"
+	CreditReasonRideReopened         CreditReason = "ride_reopened"
+	CreditReasonParticipantCorrected CreditReason = "participant_corrected"
+	CreditReasonManualAdjustment     CreditReason = "manual_adjustment"
+	CreditReasonReconciliation       CreditReason = "reconciliation"
+)
+
+// CreditLedgerEntry is one change to a member's distance and ride totals.
+// Entries are never updated or deleted; User.TotalDistanceKm and TotalRides
+// are the sums of a user's entries.
+type CreditLedgerEntry struct {
+	ID     uuid.UUID  `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	UserID uuid.UUID  `gorm:"type:uuid;not null;index" json:"user_id"`
+	RideID *uuid.UUID `gorm:"type:uuid;index" json:"ride_id"`
+	Ride   *Ride      `gorm:"foreignKey:RideID" json:"-"`
+	// CompletionID is the ride completion that made a ride_completed entry
+	CompletionID *uuid.UUID   `gorm:"type:uuid;index" json:"completion_id"`
+	DistanceKm   float64      `gorm:"type:decimal(10,2);default:0" json:"distance_km"`
+	RideCount    int          `gorm:"default:0" json:"ride_count"`
+	Reason       CreditReason `gorm:"size:30;not null;index" json:"reason"`
+	Note         string       `gorm:"type:text" json:"note"`
+	ActorID      *uuid.UUID   `gorm:"type:uuid" json:"actor_id"`
+	Actor        *User        `gorm:"foreignKey:ActorID" json:"-"`
+	CreatedAt    time.Time    `gorm:"index" json:"created_at"`
+}
+
+type CreditLedgerEntryResponse struct {
+	ID         uuid.UUID     `json:"id"`
+	UserID     uuid.UUID     `json:"user_id"`
+	RideID     *uuid.UUID    `json:"ride_id"`
+	RideTitle  string        `json:"ride_title,omitempty"`
+	DistanceKm float64       `json:"distance_km"`
+	RideCount  int           `json:"ride_count"`
+	Reason     CreditReason  `json:"reason"`
+	Note       string        `json:"note"`
+	Actor      *UserResponse `json:"actor,omitempty"`
+	CreatedAt  time.Time     `json:"created_at"`
+}
+
+func (e *CreditLedgerEntry) ToResponse(viewerIsAdmin bool) CreditLedgerEntryResponse {
+	resp := CreditLedgerEntryResponse{
+		ID:         e.ID,
+		UserID:     e.UserID,
+		RideID:     e.RideID,
+		DistanceKm: e.DistanceKm,
+		RideCount:  e.RideCount,
+		Reason:     e.Reason,
+		Note:       e.Note,
+		CreatedAt:  e.CreatedAt,
+	}
+
+	if e.Ride != nil {
+		resp.RideTitle = e.Ride.Title
+	}
+
+	if e.Actor != nil && e.Actor.ID != uuid.Nil {
+		actorResp := e.Actor.ToResponse(viewerIsAdmin)
+		resp.Actor = &actorResp
+	}
+
+	return resp
+}
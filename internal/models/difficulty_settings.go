@@ -0,0 +1,18 @@
+package models
+
+import (
+	"time"
+
+	"github.com/udacc/uda-cycling-club/pkg/gpx"
+)
+
+// DifficultySettingsID is the primary key of the single settings row.
+const DifficultySettingsID = 1
+
+// DifficultySettings stores the admin-configured weights of the ride
+// difficulty formula.
+type DifficultySettings struct {
+	ID        uint                  `gorm:"primaryKey" json:"-"`
+	Weights   gpx.DifficultyWeights `gorm:"embedded" json:"weights"`
+	UpdatedAt time.Time             `json:"updated_at"`
+}
@@ -0,0 +1,63 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// EligibilityRule restricts who may register for a ride. A rule is attached
+// either to a single ride or to a ride type; when both exist, a member must
+// satisfy both. Zero-valued criteria are not checked.
+type EligibilityRule struct {
+	ID                       uint             `gorm:"primaryKey" json:"id"`
+	RideID                   *uuid.UUID       `gorm:"type:uuid;uniqueIndex" json:"ride_id,omitempty"`
+	RideTypeID               *uint            `gorm:"uniqueIndex" json:"ride_type_id,omitempty"`
+	MinTotalDistanceKm       float64          `gorm:"type:decimal(10,2);default:0" json:"min_total_distance_km"`
+	MinCompletedRides        int              `gorm:"default:0" json:"min_completed_rides"`
+	MinCompletedRidesTypeID  *uint            `json:"min_completed_rides_type_id"`
+	RequiredMembershipStatus MembershipStatus `gorm:"size:20" json:"required_membership_status"`
+	RequiredRideID           *uuid.UUID       `gorm:"type:uuid" json:"required_ride_id"`
+	CreatedAt                time.Time        `json:"created_at"`
+	UpdatedAt                time.Time        `json:"updated_at"`
+}
+
+// EligibilityOverride lets a specific member register for a ride regardless
+// of its eligibility rules.
+type EligibilityOverride struct {
+	ID          uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	RideID      uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_override_ride_user" json:"ride_id"`
+	UserID      uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_override_ride_user" json:"user_id"`
+	User        User      `gorm:"foreignKey:UserID" json:"-"`
+	GrantedByID uuid.UUID `gorm:"type:uuid;not null" json:"granted_by_id"`
+	Reason      string    `gorm:"type:text" json:"reason"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+type EligibilityOverrideResponse struct {
+	ID          uuid.UUID     `json:"id"`
+	RideID      uuid.UUID     `json:"ride_id"`
+	UserID      uuid.UUID     `json:"user_id"`
+	User        *UserResponse `json:"user,omitempty"`
+	GrantedByID uuid.UUID     `json:"granted_by_id"`
+	Reason      string        `json:"reason"`
+	CreatedAt   time.Time     `json:"created_at"`
+}
+
+func (o *EligibilityOverride) ToResponse(viewerIsAdmin bool) EligibilityOverrideResponse {
+	resp := EligibilityOverrideResponse{
+		ID:          o.ID,
+		RideID:      o.RideID,
+		UserID:      o.UserID,
+		GrantedByID: o.GrantedByID,
+		Reason:      o.Reason,
+		CreatedAt:   o.CreatedAt,
+	}
+
+	if o.User.ID != uuid.Nil {
+		userResp := o.User.ToResponse(viewerIsAdmin)
+		resp.User = &userResp
+	}
+
+	return resp
+}
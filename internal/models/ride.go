@@ -33,19 +33,38 @@ type Ride struct {
 	MaxGradient     float64        `gorm:"type:decimal(5,2);default:0" json:"max_gradient"`
 	MaxDescent      float64        `gorm:"type:decimal(5,2);default:0" json:"max_descent"`
 	PassCount       int            `gorm:"default:0" json:"pass_count"`
+	DifficultyScore float64        `gorm:"type:decimal(6,1);default:0" json:"difficulty_score"`
+	DifficultyLevel int            `gorm:"default:1;index" json:"difficulty_level"`
 	StartTime       *time.Time     `json:"start_time"`
+	EstimatedDurationMinutes *int  `json:"estimated_duration_minutes"`
 	MeetingPointName string        `gorm:"size:255" json:"meeting_point_name"`
 	MeetingPointLat  *float64      `gorm:"type:decimal(10,8)" json:"meeting_point_lat"`
 	MeetingPointLng  *float64      `gorm:"type:decimal(11,8)" json:"meeting_point_lng"`
+	CheckInRadiusMeters *int       `json:"check_in_radius_meters"`
+	MaxParticipants *int           `json:"max_participants"`
 	Status          RideStatus     `gorm:"size:20;default:'draft'" json:"status"`
 	BonusPercentage float64        `gorm:"type:decimal(5,2);default:0" json:"bonus_percentage"`
+	FeeAmount       float64        `gorm:"type:decimal(10,2);default:0" json:"fee_amount"`
+	FeeCurrency     string         `gorm:"size:3;default:'MNT'" json:"fee_currency"`
+	PaymentWindowHours *int        `json:"payment_window_hours"`
+	PublishedAt     *time.Time     `gorm:"index" json:"published_at"`
 	StartedAt       *time.Time     `json:"started_at"`
 	CompletedAt     *time.Time     `json:"completed_at"`
+	// Set while an admin corrects a reopened ride; the scheduler won't
+	// auto-complete it until it is completed by hand
+	ReopenedAt      *time.Time     `json:"reopened_at"`
+	AutoCompleted   bool           `gorm:"default:false" json:"auto_completed"`
+	NeedsReview     bool           `gorm:"default:false;index" json:"needs_review"`
+	CheckInNonce    string         `gorm:"size:64" json:"-"`
+	CalendarSequence int           `gorm:"default:0" json:"-"`
 	CreatedAt       time.Time      `json:"created_at"`
 	UpdatedAt       time.Time      `json:"updated_at"`
 	DeletedAt       gorm.DeletedAt `gorm:"index" json:"-"`
 
 	Participants    []RideParticipant `gorm:"foreignKey:RideID" json:"participants,omitempty"`
+	Staff           []RideStaff       `gorm:"foreignKey:RideID" json:"staff,omitempty"`
+	Groups          []RideGroup       `gorm:"foreignKey:RideID" json:"groups,omitempty"`
+	Report          *RideReport       `gorm:"foreignKey:RideID" json:"report,omitempty"`
 }
 
 type RideResponse struct {
@@ -64,18 +83,78 @@ type RideResponse struct {
 	MaxGradient      float64       `json:"max_gradient"`
 	MaxDescent       float64       `json:"max_descent"`
 	PassCount        int           `json:"pass_count"`
+	DifficultyScore  float64       `json:"difficulty_score"`
+	DifficultyLevel  int           `json:"difficulty_level"`
 	StartTime        *time.Time    `json:"start_time"`
+	EstimatedDurationMinutes *int  `json:"estimated_duration_minutes"`
 	MeetingPointName string        `json:"meeting_point_name"`
 	MeetingPointLat  *float64      `json:"meeting_point_lat"`
 	MeetingPointLng  *float64      `json:"meeting_point_lng"`
+	CheckInRadiusMeters *int       `json:"check_in_radius_meters"`
+	MaxParticipants *int           `json:"max_participants"`
 	Status           RideStatus    `json:"status"`
 	BonusPercentage  float64       `json:"bonus_percentage"`
+	FeeAmount        float64       `json:"fee_amount"`
+	FeeCurrency      string        `json:"fee_currency"`
+	PaymentWindowHours *int        `json:"payment_window_hours"`
+	PublishedAt      *time.Time    `json:"published_at"`
 	StartedAt        *time.Time    `json:"started_at"`
 	CompletedAt      *time.Time    `json:"completed_at"`
+	ReopenedAt       *time.Time    `json:"reopened_at"`
+	AutoCompleted    bool          `json:"auto_completed"`
+	NeedsReview      bool          `json:"needs_review"`
 	ParticipantCount int           `json:"participant_count"`
+	Staff            []RideStaffResponse `json:"staff,omitempty"`
+	Groups           []RideGroupResponse `json:"groups,omitempty"`
+	Report           *RideReportResponse `json:"report,omitempty"`
 	CreatedAt        time.Time     `json:"created_at"`
 }
 
+// EstimatedDuration returns how long the ride is expected to take, falling
+// back to the ride type's default when the ride has no estimate of its own.
+// The ride type must be loaded for the fallback to apply.
+func (r *Ride) EstimatedDuration() time.Duration {
+	if r.EstimatedDurationMinutes != nil && *r.EstimatedDurationMinutes > 0 {
+		return time.Duration(*r.EstimatedDurationMinutes) * time.Minute
+	}
+	return time.Duration(r.RideType.DefaultDurationMinutes) * time.Minute
+}
+
+// EstimatedEnd returns the planned finish time, or nil if the ride has no
+// start time or no duration estimate.
+func (r *Ride) EstimatedEnd() *time.Time {
+	duration := r.EstimatedDuration()
+	if r.StartTime == nil || duration == 0 {
+		return nil
+	}
+	end := r.StartTime.Add(duration)
+	return &end
+}
+
+// IsFull reports whether the ride has reached its participant limit.
+func (r *Ride) IsFull(participantCount int64) bool {
+	return r.MaxParticipants != nil && participantCount >= int64(*r.MaxParticipants)
+}
+
+// HasFee reports whether riders must pay to join the ride.
+func (r *Ride) HasFee() bool {
+	return r.FeeAmount > 0
+}
+
+// PaymentDueAt returns when a registration made at registeredAt must be paid
+// for, or nil if unpaid registrations never expire. The deadline never falls
+// after the ride starts.
+func (r *Ride) PaymentDueAt(registeredAt time.Time) *time.Time {
+	if !r.HasFee() || r.PaymentWindowHours == nil || *r.PaymentWindowHours <= 0 {
+		return nil
+	}
+	dueAt := registeredAt.Add(time.Duration(*r.PaymentWindowHours) * time.Hour)
+	if r.StartTime != nil && r.StartTime.Before(dueAt) {
+		dueAt = *r.StartTime
+	}
+	return &dueAt
+}
+
 func (r *Ride) ToResponse(viewerIsAdmin bool) RideResponse {
 	resp := RideResponse{
 		ID:               r.ID,
@@ -90,14 +169,26 @@ func (r *Ride) ToResponse(viewerIsAdmin bool) RideResponse {
 		MaxGradient:      r.MaxGradient,
 		MaxDescent:       r.MaxDescent,
 		PassCount:        r.PassCount,
+		DifficultyScore:  r.DifficultyScore,
+		DifficultyLevel:  r.DifficultyLevel,
 		StartTime:        r.StartTime,
+		EstimatedDurationMinutes: r.EstimatedDurationMinutes,
 		MeetingPointName: r.MeetingPointName,
 		MeetingPointLat:  r.MeetingPointLat,
 		MeetingPointLng:  r.MeetingPointLng,
+		CheckInRadiusMeters: r.CheckInRadiusMeters,
+		MaxParticipants:  r.MaxParticipants,
 		Status:           r.Status,
 		BonusPercentage:  r.BonusPercentage,
+		FeeAmount:        r.FeeAmount,
+		FeeCurrency:      r.FeeCurrency,
+		PaymentWindowHours: r.PaymentWindowHours,
+		PublishedAt:      r.PublishedAt,
 		StartedAt:        r.StartedAt,
 		CompletedAt:      r.CompletedAt,
+		ReopenedAt:       r.ReopenedAt,
+		AutoCompleted:    r.AutoCompleted,
+		NeedsReview:      r.NeedsReview,
 		ParticipantCount: len(r.Participants),
 		CreatedAt:        r.CreatedAt,
 	}
@@ -116,5 +207,31 @@ func (r *Ride) ToResponse(viewerIsAdmin bool) RideResponse {
 		resp.Leader = &leaderResp
 	}
 
+	if len(r.Staff) > 0 {
+		resp.Staff = make([]RideStaffResponse, len(r.Staff))
+		for i, s := range r.Staff {
+			resp.Staff[i] = s.ToResponse(viewerIsAdmin)
+		}
+	}
+
+	if len(r.Groups) > 0 {
+		memberCounts := make(map[uuid.UUID]int)
+		for _, p := range r.Participants {
+			if p.GroupID != nil {
+				memberCounts[*p.GroupID]++
+			}
+		}
+
+		resp.Groups = make([]RideGroupResponse, len(r.Groups))
+		for i, g := range r.Groups {
+			resp.Groups[i] = g.ToResponse(viewerIsAdmin, memberCounts[g.ID])
+		}
+	}
+
+	if r.Report != nil && r.Report.ID != uuid.Nil {
+		reportResp := r.Report.ToResponse(viewerIsAdmin)
+		resp.Report = &reportResp
+	}
+
 	return resp
 }
@@ -0,0 +1,75 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type RideComment struct {
+	ID        uuid.UUID      `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	RideID    uuid.UUID      `gorm:"type:uuid;not null;index" json:"ride_id"`
+	UserID    uuid.UUID      `gorm:"type:uuid;not null" json:"user_id"`
+	User      User           `gorm:"foreignKey:UserID" json:"-"`
+	ParentID  *uuid.UUID     `gorm:"type:uuid;index" json:"parent_id"`
+	Body      string         `gorm:"type:text;not null" json:"body"`
+	EditedAt  *time.Time     `json:"edited_at"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+
+	Replies  []RideComment    `gorm:"foreignKey:ParentID" json:"-"`
+	Mentions []CommentMention `gorm:"foreignKey:CommentID" json:"-"`
+}
+
+// CommentMention records a user @mentioned in a comment.
+type CommentMention struct {
+	CommentID uuid.UUID `gorm:"type:uuid;primaryKey" json:"comment_id"`
+	UserID    uuid.UUID `gorm:"type:uuid;primaryKey" json:"user_id"`
+	User      User      `gorm:"foreignKey:UserID" json:"-"`
+}
+
+type CommentResponse struct {
+	ID        uuid.UUID         `json:"id"`
+	RideID    uuid.UUID         `json:"ride_id"`
+	ParentID  *uuid.UUID        `json:"parent_id"`
+	User      *UserResponse     `json:"user,omitempty"`
+	Body      string            `json:"body"`
+	Mentions  []UserResponse    `json:"mentions"`
+	EditedAt  *time.Time        `json:"edited_at"`
+	CreatedAt time.Time         `json:"created_at"`
+	Replies   []CommentResponse `json:"replies,omitempty"`
+}
+
+func (rc *RideComment) ToResponse(viewerIsAdmin bool) CommentResponse {
+	resp := CommentResponse{
+		ID:        rc.ID,
+		RideID:    rc.RideID,
+		ParentID:  rc.ParentID,
+		Body:      rc.Body,
+		Mentions:  make([]UserResponse, 0, len(rc.Mentions)),
+		EditedAt:  rc.EditedAt,
+		CreatedAt: rc.CreatedAt,
+	}
+
+	if rc.User.ID != uuid.Nil {
+		userResp := rc.User.ToResponse(viewerIsAdmin)
+		resp.User = &userResp
+	}
+
+	for _, m := range rc.Mentions {
+		if m.User.ID != uuid.Nil {
+			resp.Mentions = append(resp.Mentions, m.User.ToResponse(viewerIsAdmin))
+		}
+	}
+
+	if len(rc.Replies) > 0 {
+		resp.Replies = make([]CommentResponse, len(rc.Replies))
+		for i, reply := range rc.Replies {
+			resp.Replies[i] = reply.ToResponse(viewerIsAdmin)
+		}
+	}
+
+	return resp
+}
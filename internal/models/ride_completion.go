@@ -0,0 +1,43 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// RideCompletion records one completion of a ride. A ride that is reopened
+// and completed again has several. IdempotencyKey is the client-supplied
+// key, so a retried request returns the original result instead of
+// crediting participants twice.
+type RideCompletion struct {
+	ID              uuid.UUID  `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	RideID          uuid.UUID  `gorm:"type:uuid;not null;index" json:"ride_id"`
+	IdempotencyKey  *string    `gorm:"size:255;uniqueIndex" json:"-"`
+	ActorID         *uuid.UUID `gorm:"type:uuid" json:"actor_id"`
+	AutoCompleted   bool       `gorm:"default:false" json:"auto_completed"`
+	CreditedCount   int        `gorm:"default:0" json:"credited_count"`
+	TotalDistanceKm float64    `gorm:"type:decimal(10,2);default:0" json:"total_distance_km"`
+	CreatedAt       time.Time  `json:"created_at"`
+}
+
+type CreditedParticipant struct {
+	UserID          uuid.UUID `json:"user_id"`
+	FinalDistanceKm float64   `json:"final_distance_km"`
+}
+
+// RideCompletionSummary is returned when a ride is completed. Replayed is
+// set when the result comes from an earlier request with the same
+// idempotency key, and Superseded when the ride has been reopened since, so
+// that completion's credits no longer stand.
+type RideCompletionSummary struct {
+	CompletionID    uuid.UUID             `json:"completion_id"`
+	RideID          uuid.UUID             `json:"ride_id"`
+	CompletedAt     time.Time             `json:"completed_at"`
+	CreditedCount   int                   `json:"credited_count"`
+	TotalDistanceKm float64               `json:"total_distance_km"`
+	Credited        []CreditedParticipant `json:"credited"`
+	Badges          []UserBadgeResponse   `json:"badges"`
+	Replayed        bool                  `json:"replayed"`
+	Superseded      bool                  `json:"superseded"`
+}
@@ -0,0 +1,59 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// RideGroup is a pace group within a ride, such as a fast group and a
+// social group, each with its own leader.
+type RideGroup struct {
+	ID            uuid.UUID  `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	RideID        uuid.UUID  `gorm:"type:uuid;not null;index" json:"ride_id"`
+	Name          string     `gorm:"size:100;not null" json:"name"`
+	LeaderID      *uuid.UUID `gorm:"type:uuid" json:"leader_id"`
+	Leader        *User      `gorm:"foreignKey:LeaderID" json:"leader,omitempty"`
+	TargetPaceKmh float64    `gorm:"type:decimal(5,2);default:0" json:"target_pace_kmh"`
+	Capacity      *int       `json:"capacity"`
+	SortOrder     int        `gorm:"default:0" json:"sort_order"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
+
+type RideGroupResponse struct {
+	ID            uuid.UUID     `json:"id"`
+	RideID        uuid.UUID     `json:"ride_id"`
+	Name          string        `json:"name"`
+	LeaderID      *uuid.UUID    `json:"leader_id"`
+	Leader        *UserResponse `json:"leader,omitempty"`
+	TargetPaceKmh float64       `json:"target_pace_kmh"`
+	Capacity      *int          `json:"capacity"`
+	MemberCount   int           `json:"member_count"`
+	SortOrder     int           `json:"sort_order"`
+}
+
+func (g *RideGroup) ToResponse(viewerIsAdmin bool, memberCount int) RideGroupResponse {
+	resp := RideGroupResponse{
+		ID:            g.ID,
+		RideID:        g.RideID,
+		Name:          g.Name,
+		LeaderID:      g.LeaderID,
+		TargetPaceKmh: g.TargetPaceKmh,
+		Capacity:      g.Capacity,
+		MemberCount:   memberCount,
+		SortOrder:     g.SortOrder,
+	}
+
+	if g.Leader != nil && g.Leader.ID != uuid.Nil {
+		leaderResp := g.Leader.ToResponse(viewerIsAdmin)
+		resp.Leader = &leaderResp
+	}
+
+	return resp
+}
+
+// IsFull reports whether the group has reached its capacity.
+func (g *RideGroup) IsFull(memberCount int) bool {
+	return g.Capacity != nil && memberCount >= *g.Capacity
+}
@@ -0,0 +1,137 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type IncidentType string
+
+const (
+	IncidentTypeCrash      IncidentType = "crash"
+	IncidentTypeInjury     IncidentType = "injury"
+	IncidentTypeMedical    IncidentType = "medical"
+	IncidentTypeMechanical IncidentType = "mechanical"
+	IncidentTypeTraffic    IncidentType = "traffic"
+	IncidentTypeOther      IncidentType = "other"
+)
+
+func (t IncidentType) IsValid() bool {
+	switch t {
+	case IncidentTypeCrash, IncidentTypeInjury, IncidentTypeMedical,
+		IncidentTypeMechanical, IncidentTypeTraffic, IncidentTypeOther:
+		return true
+	}
+	return false
+}
+
+type IncidentSeverity string
+
+const (
+	IncidentSeverityMinor    IncidentSeverity = "minor"
+	IncidentSeverityModerate IncidentSeverity = "moderate"
+	IncidentSeveritySerious  IncidentSeverity = "serious"
+	IncidentSeverityCritical IncidentSeverity = "critical"
+)
+
+func (s IncidentSeverity) IsValid() bool {
+	switch s {
+	case IncidentSeverityMinor, IncidentSeverityModerate, IncidentSeveritySerious, IncidentSeverityCritical:
+		return true
+	}
+	return false
+}
+
+type IncidentStatus string
+
+const (
+	IncidentStatusOpen     IncidentStatus = "open"
+	IncidentStatusReviewed IncidentStatus = "reviewed"
+)
+
+// RideIncident records a crash, injury or other event during a ride.
+type RideIncident struct {
+	ID                  uuid.UUID        `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	RideID              uuid.UUID        `gorm:"type:uuid;not null;index" json:"ride_id"`
+	Ride                Ride             `gorm:"foreignKey:RideID" json:"-"`
+	ReportedByID        uuid.UUID        `gorm:"type:uuid;not null" json:"reported_by_id"`
+	ReportedBy          User             `gorm:"foreignKey:ReportedByID" json:"-"`
+	Type                IncidentType     `gorm:"size:20;not null;index" json:"type"`
+	Severity            IncidentSeverity `gorm:"size:20;not null;index" json:"severity"`
+	Description         string           `gorm:"type:text" json:"description"`
+	OccurredAt          *time.Time       `json:"occurred_at"`
+	LocationDescription string           `gorm:"size:255" json:"location_description"`
+	Lat                 *float64         `gorm:"type:decimal(10,8)" json:"lat"`
+	Lng                 *float64         `gorm:"type:decimal(11,8)" json:"lng"`
+	ActionsTaken        string           `gorm:"type:text" json:"actions_taken"`
+	Status              IncidentStatus   `gorm:"size:20;default:'open';index" json:"status"`
+	ReviewedByID        *uuid.UUID       `gorm:"type:uuid" json:"reviewed_by_id"`
+	ReviewedBy          *User            `gorm:"foreignKey:ReviewedByID" json:"-"`
+	ReviewedAt          *time.Time       `json:"reviewed_at"`
+	ReviewNotes         string           `gorm:"type:text" json:"review_notes"`
+	CreatedAt           time.Time        `json:"created_at"`
+	UpdatedAt           time.Time        `json:"updated_at"`
+
+	Involved []User `gorm:"many2many:ride_incident_users" json:"-"`
+}
+
+type IncidentResponse struct {
+	ID                  uuid.UUID        `json:"id"`
+	RideID              uuid.UUID        `json:"ride_id"`
+	RideTitle           string           `json:"ride_title,omitempty"`
+	ReportedBy          *UserResponse    `json:"reported_by,omitempty"`
+	Type                IncidentType     `json:"type"`
+	Severity            IncidentSeverity `json:"severity"`
+	Description         string           `json:"description"`
+	OccurredAt          *time.Time       `json:"occurred_at"`
+	LocationDescription string           `json:"location_description"`
+	Lat                 *float64         `json:"lat"`
+	Lng                 *float64         `json:"lng"`
+	ActionsTaken        string           `json:"actions_taken"`
+	Involved            []UserResponse   `json:"involved"`
+	Status              IncidentStatus   `json:"status"`
+	ReviewedBy          *UserResponse    `json:"reviewed_by,omitempty"`
+	ReviewedAt          *time.Time       `json:"reviewed_at"`
+	ReviewNotes         string           `json:"review_notes"`
+	CreatedAt           time.Time        `json:"created_at"`
+}
+
+// ToResponse builds the response. Incidents are only shown to ride staff and
+// admins, so involved riders' names are never masked.
+func (ri *RideIncident) ToResponse() IncidentResponse {
+	resp := IncidentResponse{
+		ID:                  ri.ID,
+		RideID:              ri.RideID,
+		RideTitle:           ri.Ride.Title,
+		Type:                ri.Type,
+		Severity:            ri.Severity,
+		Description:         ri.Description,
+		OccurredAt:          ri.OccurredAt,
+		LocationDescription: ri.LocationDescription,
+		Lat:                 ri.Lat,
+		Lng:                 ri.Lng,
+		ActionsTaken:        ri.ActionsTaken,
+		Involved:            make([]UserResponse, len(ri.Involved)),
+		Status:              ri.Status,
+		ReviewedAt:          ri.ReviewedAt,
+		ReviewNotes:         ri.ReviewNotes,
+		CreatedAt:           ri.CreatedAt,
+	}
+
+	if ri.ReportedBy.ID != uuid.Nil {
+		reportedByResp := ri.ReportedBy.ToResponse(true)
+		resp.ReportedBy = &reportedByResp
+	}
+
+	if ri.ReviewedBy != nil && ri.ReviewedBy.ID != uuid.Nil {
+		reviewedByResp := ri.ReviewedBy.ToResponse(true)
+		resp.ReviewedBy = &reviewedByResp
+	}
+
+	for i, u := range ri.Involved {
+		resp.Involved[i] = u.ToResponse(true)
+	}
+
+	return resp
+}
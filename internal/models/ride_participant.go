@@ -6,12 +6,55 @@ import (
 	"github.com/google/uuid"
 )
 
+type CheckInMethod string
+
+const (
+	CheckInMethodCode     CheckInMethod = "code"
+	CheckInMethodLocation CheckInMethod = "location"
+)
+
+type PaymentStatus string
+
+const (
+	PaymentStatusNotRequired PaymentStatus = "not_required"
+	PaymentStatusPending     PaymentStatus = "pending"
+	PaymentStatusPaid        PaymentStatus = "paid"
+	PaymentStatusWaived      PaymentStatus = "waived"
+	PaymentStatusRefunded    PaymentStatus = "refunded"
+)
+
+func (s PaymentStatus) IsValid() bool {
+	switch s {
+	case PaymentStatusNotRequired, PaymentStatusPending, PaymentStatusPaid, PaymentStatusWaived, PaymentStatusRefunded:
+		return true
+	}
+	return false
+}
+
+type PaymentMethod string
+
+const (
+	PaymentMethodCash         PaymentMethod = "cash"
+	PaymentMethodBankTransfer PaymentMethod = "bank_transfer"
+	PaymentMethodOnline       PaymentMethod = "online"
+	PaymentMethodOther        PaymentMethod = "other"
+)
+
+func (m PaymentMethod) IsValid() bool {
+	switch m {
+	case PaymentMethodCash, PaymentMethodBankTransfer, PaymentMethodOnline, PaymentMethodOther:
+		return true
+	}
+	return false
+}
+
 type RideParticipant struct {
 	ID              uuid.UUID  `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
 	RideID          uuid.UUID  `gorm:"type:uuid;not null;uniqueIndex:idx_ride_user" json:"ride_id"`
 	Ride            Ride       `gorm:"foreignKey:RideID" json:"-"`
 	UserID          uuid.UUID  `gorm:"type:uuid;not null;uniqueIndex:idx_ride_user" json:"user_id"`
 	User            User       `gorm:"foreignKey:UserID" json:"user,omitempty"`
+	GroupID         *uuid.UUID `gorm:"type:uuid;index" json:"group_id"`
 	RegisteredAt    time.Time  `gorm:"not null;default:now()" json:"registered_at"`
 	Attended        bool       `gorm:"default:false" json:"attended"`
 	Completed       bool       `gorm:"default:false" json:"completed"`
@@ -19,6 +62,19 @@ type RideParticipant struct {
 	BonusPercentage  *float64  `gorm:"type:decimal(5,2)" json:"bonus_percentage"`
 	FinalDistanceKm  float64   `gorm:"type:decimal(10,2);default:0" json:"final_distance_km"`
 	Notes           string     `gorm:"type:text" json:"notes"`
+	CheckedInAt     *time.Time `json:"checked_in_at"`
+	CheckInMethod   CheckInMethod `gorm:"size:20" json:"check_in_method"`
+	CheckInLat      *float64   `gorm:"type:decimal(10,8)" json:"check_in_lat"`
+	CheckInLng      *float64   `gorm:"type:decimal(11,8)" json:"check_in_lng"`
+	CheckInAccuracyMeters *float64 `gorm:"type:decimal(8,2)" json:"check_in_accuracy_meters"`
+	CheckInDistanceMeters *float64 `gorm:"type:decimal(10,2)" json:"check_in_distance_meters"`
+	KeepPositions   bool       `gorm:"default:false" json:"keep_positions"`
+	PaymentStatus   PaymentStatus `gorm:"size:20;default:'not_required';index" json:"payment_status"`
+	AmountPaid      float64    `gorm:"type:decimal(10,2);default:0" json:"amount_paid"`
+	PaymentMethod   PaymentMethod `gorm:"size:20" json:"payment_method"`
+	PaymentReference string    `gorm:"size:255" json:"payment_reference"`
+	PaidAt          *time.Time `json:"paid_at"`
+	PaymentDueAt    *time.Time `gorm:"index" json:"payment_due_at"`
 	CreatedAt       time.Time  `json:"created_at"`
 	UpdatedAt       time.Time  `json:"updated_at"`
 }
@@ -28,6 +84,7 @@ type ParticipantResponse struct {
 	RideID           uuid.UUID     `json:"ride_id"`
 	UserID           uuid.UUID     `json:"user_id"`
 	User             *UserResponse `json:"user,omitempty"`
+	GroupID          *uuid.UUID    `json:"group_id"`
 	RegisteredAt     time.Time     `json:"registered_at"`
 	Attended         bool          `json:"attended"`
 	Completed        bool          `json:"completed"`
@@ -35,6 +92,28 @@ type ParticipantResponse struct {
 	BonusPercentage  *float64      `json:"bonus_percentage"`
 	FinalDistanceKm  float64       `json:"final_distance_km"`
 	Notes            string        `json:"notes"`
+	CheckedInAt      *time.Time    `json:"checked_in_at"`
+	CheckInMethod    CheckInMethod `json:"check_in_method,omitempty"`
+	CheckInLat       *float64      `json:"check_in_lat,omitempty"`
+	CheckInLng       *float64      `json:"check_in_lng,omitempty"`
+	CheckInAccuracyMeters *float64 `json:"check_in_accuracy_meters,omitempty"`
+	CheckInDistanceMeters *float64 `json:"check_in_distance_meters,omitempty"`
+	PaymentStatus    PaymentStatus `json:"payment_status"`
+	PaymentDueAt     *time.Time    `json:"payment_due_at,omitempty"`
+}
+
+// ParticipantPaymentResponse is the payment detail shown to ride staff and to
+// the participant themselves.
+type ParticipantPaymentResponse struct {
+	ParticipantID    uuid.UUID     `json:"participant_id"`
+	UserID           uuid.UUID     `json:"user_id"`
+	User             *UserResponse `json:"user,omitempty"`
+	PaymentStatus    PaymentStatus `json:"payment_status"`
+	AmountPaid       float64       `json:"amount_paid"`
+	PaymentMethod    PaymentMethod `json:"payment_method"`
+	PaymentReference string        `json:"payment_reference"`
+	PaidAt           *time.Time    `json:"paid_at"`
+	PaymentDueAt     *time.Time    `json:"payment_due_at"`
 }
 
 func (rp *RideParticipant) ToResponse(viewerIsAdmin bool) ParticipantResponse {
@@ -42,6 +121,7 @@ func (rp *RideParticipant) ToResponse(viewerIsAdmin bool) ParticipantResponse {
 		ID:               rp.ID,
 		RideID:           rp.RideID,
 		UserID:           rp.UserID,
+		GroupID:          rp.GroupID,
 		RegisteredAt:     rp.RegisteredAt,
 		Attended:         rp.Attended,
 		Completed:        rp.Completed,
@@ -49,8 +129,21 @@ func (rp *RideParticipant) ToResponse(viewerIsAdmin bool) ParticipantResponse {
 		BonusPercentage:  rp.BonusPercentage,
 		FinalDistanceKm:  rp.FinalDistanceKm,
 		Notes:            rp.Notes,
+		CheckedInAt:      rp.CheckedInAt,
+		CheckInMethod:    rp.CheckInMethod,
+		PaymentStatus:    rp.PaymentStatus,
+		PaymentDueAt:     rp.PaymentDueAt,
 	}
 
+	// Exact positions are only shown to admins; everyone else sees how far
+	// from the meeting point the rider checked in.
+	if viewerIsAdmin {
+		resp.CheckInLat = rp.CheckInLat
+		resp.CheckInLng = rp.CheckInLng
+		resp.CheckInAccuracyMeters = rp.CheckInAccuracyMeters
+	}
+	resp.CheckInDistanceMeters = rp.CheckInDistanceMeters
+
 	if rp.User.ID != uuid.Nil {
 		userResp := rp.User.ToResponse(viewerIsAdmin)
 		resp.User = &userResp
@@ -59,6 +152,31 @@ func (rp *RideParticipant) ToResponse(viewerIsAdmin bool) ParticipantResponse {
 	return resp
 }
 
+func (rp *RideParticipant) ToPaymentResponse(viewerIsAdmin bool) ParticipantPaymentResponse {
+	resp := ParticipantPaymentResponse{
+		ParticipantID:    rp.ID,
+		UserID:           rp.UserID,
+		PaymentStatus:    rp.PaymentStatus,
+		AmountPaid:       rp.AmountPaid,
+		PaymentMethod:    rp.PaymentMethod,
+		PaymentReference: rp.PaymentReference,
+		PaidAt:           rp.PaidAt,
+		PaymentDueAt:     rp.PaymentDueAt,
+	}
+
+	if rp.User.ID != uuid.Nil {
+		userResp := rp.User.ToResponse(viewerIsAdmin)
+		resp.User = &userResp
+	}
+
+	return resp
+}
+
+// RequiresPayment reports whether the participant still owes the ride fee.
+func (rp *RideParticipant) RequiresPayment() bool {
+	return rp.PaymentStatus == PaymentStatusPending
+}
+
 func (rp *RideParticipant) CalculateFinalDistance(rideDistanceKm, rideBonusPercentage float64) {
 	distance := rideDistanceKm
 	if rp.ActualDistanceKm != nil {
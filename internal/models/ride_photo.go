@@ -0,0 +1,65 @@
+package models
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type RidePhoto struct {
+	ID            uuid.UUID      `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	RideID        uuid.UUID      `gorm:"type:uuid;not null;index" json:"ride_id"`
+	UploadedByID  uuid.UUID      `gorm:"type:uuid;not null" json:"uploaded_by_id"`
+	UploadedBy    User           `gorm:"foreignKey:UploadedByID" json:"-"`
+	FilePath      string         `gorm:"size:500;not null" json:"-"`
+	ThumbnailPath string         `gorm:"size:500;not null" json:"-"`
+	ContentType   string         `gorm:"size:50;not null" json:"content_type"`
+	Width         int            `json:"width"`
+	Height        int            `json:"height"`
+	SizeBytes     int64          `json:"size_bytes"`
+	Caption       string         `gorm:"size:500" json:"caption"`
+	CreatedAt     time.Time      `json:"created_at"`
+	DeletedAt     gorm.DeletedAt `gorm:"index" json:"-"`
+}
+
+type PhotoResponse struct {
+	ID           uuid.UUID     `json:"id"`
+	RideID       uuid.UUID     `json:"ride_id"`
+	UploadedByID uuid.UUID     `json:"uploaded_by_id"`
+	UploadedBy   *UserResponse `json:"uploaded_by,omitempty"`
+	ImageURL     string        `json:"image_url"`
+	ThumbnailURL string        `json:"thumbnail_url"`
+	ContentType  string        `json:"content_type"`
+	Width        int           `json:"width"`
+	Height       int           `json:"height"`
+	SizeBytes    int64         `json:"size_bytes"`
+	Caption      string        `json:"caption"`
+	CreatedAt    time.Time     `json:"created_at"`
+}
+
+func (p *RidePhoto) ToResponse(viewerIsAdmin bool) PhotoResponse {
+	base := fmt.Sprintf("/api/v1/rides/%s/photos/%s", p.RideID, p.ID)
+
+	resp := PhotoResponse{
+		ID:           p.ID,
+		RideID:       p.RideID,
+		UploadedByID: p.UploadedByID,
+		ImageURL:     base + "/image",
+		ThumbnailURL: base + "/thumbnail",
+		ContentType:  p.ContentType,
+		Width:        p.Width,
+		Height:       p.Height,
+		SizeBytes:    p.SizeBytes,
+		Caption:      p.Caption,
+		CreatedAt:    p.CreatedAt,
+	}
+
+	if p.UploadedBy.ID != uuid.Nil {
+		userResp := p.UploadedBy.ToResponse(viewerIsAdmin)
+		resp.UploadedBy = &userResp
+	}
+
+	return resp
+}
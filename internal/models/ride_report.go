@@ -0,0 +1,85 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type WeatherCondition string
+
+const (
+	WeatherClear  WeatherCondition = "clear"
+	WeatherCloudy WeatherCondition = "cloudy"
+	WeatherRain   WeatherCondition = "rain"
+	WeatherSnow   WeatherCondition = "snow"
+	WeatherWindy  WeatherCondition = "windy"
+	WeatherFog    WeatherCondition = "fog"
+)
+
+func (w WeatherCondition) IsValid() bool {
+	switch w {
+	case WeatherClear, WeatherCloudy, WeatherRain, WeatherSnow, WeatherWindy, WeatherFog:
+		return true
+	}
+	return false
+}
+
+// RideReport is the leader's account of how a completed ride went.
+// Each ride has at most one report; resubmitting replaces it.
+type RideReport struct {
+	ID              uuid.UUID        `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	RideID          uuid.UUID        `gorm:"type:uuid;not null;uniqueIndex" json:"ride_id"`
+	AuthorID        uuid.UUID        `gorm:"type:uuid;not null" json:"author_id"`
+	Author          User             `gorm:"foreignKey:AuthorID" json:"-"`
+	ActualStartAt   *time.Time       `json:"actual_start_at"`
+	ActualFinishAt  *time.Time       `json:"actual_finish_at"`
+	Weather         WeatherCondition `gorm:"size:20" json:"weather"`
+	TemperatureC    *float64         `gorm:"type:decimal(4,1)" json:"temperature_c"`
+	RouteDeviations string           `gorm:"type:text" json:"route_deviations"`
+	Mechanicals     string           `gorm:"type:text" json:"mechanicals"`
+	Incidents       string           `gorm:"type:text" json:"incidents"`
+	Summary         string           `gorm:"type:text" json:"summary"`
+	CreatedAt       time.Time        `json:"created_at"`
+	UpdatedAt       time.Time        `json:"updated_at"`
+}
+
+type RideReportResponse struct {
+	ID              uuid.UUID        `json:"id"`
+	RideID          uuid.UUID        `json:"ride_id"`
+	Author          *UserResponse    `json:"author,omitempty"`
+	ActualStartAt   *time.Time       `json:"actual_start_at"`
+	ActualFinishAt  *time.Time       `json:"actual_finish_at"`
+	Weather         WeatherCondition `json:"weather"`
+	TemperatureC    *float64         `json:"temperature_c"`
+	RouteDeviations string           `json:"route_deviations"`
+	Mechanicals     string           `json:"mechanicals"`
+	Incidents       string           `json:"incidents"`
+	Summary         string           `json:"summary"`
+	CreatedAt       time.Time        `json:"created_at"`
+	UpdatedAt       time.Time        `json:"updated_at"`
+}
+
+func (rr *RideReport) ToResponse(viewerIsAdmin bool) RideReportResponse {
+	resp := RideReportResponse{
+		ID:              rr.ID,
+		RideID:          rr.RideID,
+		ActualStartAt:   rr.ActualStartAt,
+		ActualFinishAt:  rr.ActualFinishAt,
+		Weather:         rr.Weather,
+		TemperatureC:    rr.TemperatureC,
+		RouteDeviations: rr.RouteDeviations,
+		Mechanicals:     rr.Mechanicals,
+		Incidents:       rr.Incidents,
+		Summary:         rr.Summary,
+		CreatedAt:       rr.CreatedAt,
+		UpdatedAt:       rr.UpdatedAt,
+	}
+
+	if rr.Author.ID != uuid.Nil {
+		authorResp := rr.Author.ToResponse(viewerIsAdmin)
+		resp.Author = &authorResp
+	}
+
+	return resp
+}
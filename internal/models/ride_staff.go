@@ -0,0 +1,59 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type StaffRole string
+
+const (
+	StaffRoleLeader   StaffRole = "leader"
+	StaffRoleCoLeader StaffRole = "co_leader"
+	StaffRoleSweep    StaffRole = "sweep"
+	StaffRoleMedic    StaffRole = "medic"
+)
+
+func (r StaffRole) IsValid() bool {
+	switch r {
+	case StaffRoleLeader, StaffRoleCoLeader, StaffRoleSweep, StaffRoleMedic:
+		return true
+	}
+	return false
+}
+
+type RideStaff struct {
+	ID           uuid.UUID  `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	RideID       uuid.UUID  `gorm:"type:uuid;not null;uniqueIndex:idx_ride_staff_user" json:"ride_id"`
+	UserID       uuid.UUID  `gorm:"type:uuid;not null;uniqueIndex:idx_ride_staff_user" json:"user_id"`
+	User         User       `gorm:"foreignKey:UserID" json:"user,omitempty"`
+	Role         StaffRole  `gorm:"size:20;not null" json:"role"`
+	AssignedByID *uuid.UUID `gorm:"type:uuid" json:"assigned_by_id"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+}
+
+type RideStaffResponse struct {
+	ID     uuid.UUID     `json:"id"`
+	RideID uuid.UUID     `json:"ride_id"`
+	UserID uuid.UUID     `json:"user_id"`
+	User   *UserResponse `json:"user,omitempty"`
+	Role   StaffRole     `json:"role"`
+}
+
+func (s *RideStaff) ToResponse(viewerIsAdmin bool) RideStaffResponse {
+	resp := RideStaffResponse{
+		ID:     s.ID,
+		RideID: s.RideID,
+		UserID: s.UserID,
+		Role:   s.Role,
+	}
+
+	if s.User.ID != uuid.Nil {
+		userResp := s.User.ToResponse(viewerIsAdmin)
+		resp.User = &userResp
+	}
+
+	return resp
+}
@@ -5,18 +5,35 @@ import (
 )
 
 type RideType struct {
-	ID          uint      `gorm:"primaryKey" json:"id"`
-	Name        string    `gorm:"uniqueIndex;size:100;not null" json:"name"`
-	Description string    `gorm:"type:text" json:"description"`
-	SortOrder   int       `gorm:"default:0" json:"sort_order"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
+	ID          uint   `gorm:"primaryKey" json:"id"`
+	Name        string `gorm:"uniqueIndex;size:100;not null" json:"name"`
+	Description string `gorm:"type:text" json:"description"`
+	SortOrder   int    `gorm:"default:0" json:"sort_order"`
+
+	// Scheduler policy: whether rides of this type are started/completed
+	// automatically, and how long they are expected to last when the ride
+	// itself has no estimate.
+	AutoStart              bool `gorm:"default:false" json:"auto_start"`
+	AutoComplete           bool `gorm:"default:false" json:"auto_complete"`
+	DefaultDurationMinutes int  `gorm:"default:0" json:"default_duration_minutes"`
+	CompletionGraceMinutes int  `gorm:"default:0" json:"completion_grace_minutes"`
+	// PolicyConfiguredAt is when the policy was first set. Types created
+	// before policies existed have none and are given the defaults once.
+	PolicyConfiguredAt *time.Time `json:"-"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
 }
 
 var DefaultRideTypes = []RideType{
-	{ID: 1, Name: "Жийлт", Description: "Богино зайн жийлт", SortOrder: 1},
-	{ID: 2, Name: "Оройн жийлт", Description: "Оройн цагийн жийлт", SortOrder: 2},
-	{ID: 3, Name: "Өдрийн аялал", Description: "Нэг өдрийн аялал", SortOrder: 3},
-	{ID: 4, Name: "Хоногийн аялал", Description: "Нэг хоногийн аялал", SortOrder: 4},
-	{ID: 5, Name: "Олон хоногийн аялал", Description: "Олон хоногийн урт аялал", SortOrder: 5},
+	{ID: 1, Name: "Жийлт", Description: "Богино зайн жийлт", SortOrder: 1,
+		AutoStart: true, AutoComplete: true, DefaultDurationMinutes: 180, CompletionGraceMinutes: 120},
+	{ID: 2, Name: "Оройн жийлт", Description: "Оройн цагийн жийлт", SortOrder: 2,
+		AutoStart: true, AutoComplete: true, DefaultDurationMinutes: 180, CompletionGraceMinutes: 120},
+	{ID: 3, Name: "Өдрийн аялал", Description: "Нэг өдрийн аялал", SortOrder: 3,
+		AutoStart: true, AutoComplete: true, DefaultDurationMinutes: 600, CompletionGraceMinutes: 240},
+	{ID: 4, Name: "Хоногийн аялал", Description: "Нэг хоногийн аялал", SortOrder: 4,
+		AutoStart: true, AutoComplete: true, DefaultDurationMinutes: 1440, CompletionGraceMinutes: 720},
+	{ID: 5, Name: "Олон хоногийн аялал", Description: "Олон хоногийн урт аялал", SortOrder: 5,
+		AutoStart: true, AutoComplete: false},
 }
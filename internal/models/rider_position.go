@@ -0,0 +1,34 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// RiderPosition is a location reported by a participant during an ongoing
+// ride. Positions are purged when the ride completes unless the rider chose
+// to keep them.
+type RiderPosition struct {
+	ID             uint64    `gorm:"primaryKey" json:"id"`
+	RideID         uuid.UUID `gorm:"type:uuid;not null;index:idx_position_ride_user" json:"ride_id"`
+	UserID         uuid.UUID `gorm:"type:uuid;not null;index:idx_position_ride_user" json:"user_id"`
+	User           User      `gorm:"foreignKey:UserID" json:"-"`
+	Lat            float64   `gorm:"type:decimal(10,8);not null" json:"lat"`
+	Lng            float64   `gorm:"type:decimal(11,8);not null" json:"lng"`
+	AccuracyMeters *float64  `gorm:"type:decimal(8,2)" json:"accuracy_meters"`
+	RecordedAt     time.Time `gorm:"not null;index" json:"recorded_at"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// LivePosition is a rider's most recent position as shown to ride staff.
+type LivePosition struct {
+	User                 UserResponse `json:"user"`
+	Lat                  float64      `json:"lat"`
+	Lng                  float64      `json:"lng"`
+	AccuracyMeters       *float64     `json:"accuracy_meters"`
+	RecordedAt           time.Time    `json:"recorded_at"`
+	SecondsSinceUpdate   int          `json:"seconds_since_update"`
+	DistanceAlongRouteKm *float64     `json:"distance_along_route_km,omitempty"`
+	DistanceFromRouteKm  *float64     `json:"distance_from_route_km,omitempty"`
+}
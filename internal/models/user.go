@@ -9,6 +9,30 @@ import (
 	"gorm.io/gorm"
 )
 
+type MembershipStatus string
+
+const (
+	MembershipStatusActive   MembershipStatus = "active"
+	MembershipStatusGuest    MembershipStatus = "guest"
+	MembershipStatusInactive MembershipStatus = "inactive"
+)
+
+func (m MembershipStatus) IsValid() bool {
+	switch m {
+	case MembershipStatusActive, MembershipStatusGuest, MembershipStatusInactive:
+		return true
+	}
+	return false
+}
+
+// EmergencyContact is who to call if the user is hurt on a ride. It is only
+// shown to the user, admins, and staff of rides the user is registered for.
+type EmergencyContact struct {
+	Name         string `gorm:"size:200" json:"name"`
+	Phone        string `gorm:"size:20" json:"phone"`
+	Relationship string `gorm:"size:50" json:"relationship"`
+}
+
 type User struct {
 	ID           uuid.UUID      `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
 	Email        string         `gorm:"uniqueIndex;size:255;not null" json:"email"`
@@ -23,8 +47,13 @@ type User struct {
 	IsPrivate    bool           `gorm:"default:false" json:"is_private"`
 	IsRideLeader bool           `gorm:"default:false" json:"is_ride_leader"`
 	IsAdmin      bool           `gorm:"default:false" json:"is_admin"`
+	MembershipStatus MembershipStatus `gorm:"size:20;default:'active'" json:"membership_status"`
+	EmergencyContact EmergencyContact `gorm:"embedded;embeddedPrefix:emergency_contact_" json:"-"`
+	CalendarToken *string       `gorm:"size:64;uniqueIndex" json:"-"`
 	TotalDistanceKm float64     `gorm:"type:decimal(10,2);default:0" json:"total_distance_km"`
 	TotalRides   int            `gorm:"default:0" json:"total_rides"`
+	Badges       []UserBadge    `gorm:"foreignKey:UserID" json:"-"`
+	Streaks      []UserStreak   `gorm:"foreignKey:UserID" json:"-"`
 	CreatedAt    time.Time      `json:"created_at"`
 	UpdatedAt    time.Time      `json:"updated_at"`
 	DeletedAt    gorm.DeletedAt `gorm:"index" json:"-"`
@@ -82,8 +111,11 @@ type UserResponse struct {
 	IsPrivate       bool      `json:"is_private"`
 	IsRideLeader    bool      `json:"is_ride_leader"`
 	IsAdmin         bool      `json:"is_admin"`
+	MembershipStatus MembershipStatus `json:"membership_status"`
 	TotalDistanceKm float64   `json:"total_distance_km"`
 	TotalRides      int       `json:"total_rides"`
+	Badges          []UserBadgeResponse `json:"badges,omitempty"`
+	Streaks         []UserStreakResponse `json:"streaks,omitempty"`
 	CreatedAt       time.Time `json:"created_at"`
 }
 
@@ -97,6 +129,7 @@ func (u *User) ToResponse(viewerIsAdmin bool) UserResponse {
 		IsPrivate:       u.IsPrivate,
 		IsRideLeader:    u.IsRideLeader,
 		IsAdmin:         u.IsAdmin,
+		MembershipStatus: u.MembershipStatus,
 		TotalDistanceKm: u.TotalDistanceKm,
 		TotalRides:      u.TotalRides,
 		CreatedAt:       u.CreatedAt,
@@ -109,5 +142,19 @@ func (u *User) ToResponse(viewerIsAdmin bool) UserResponse {
 		resp.StravaURL = u.StravaURL
 	}
 
+	if len(u.Badges) > 0 {
+		resp.Badges = make([]UserBadgeResponse, len(u.Badges))
+		for i := range u.Badges {
+			resp.Badges[i] = u.Badges[i].ToResponse()
+		}
+	}
+
+	if len(u.Streaks) > 0 {
+		resp.Streaks = make([]UserStreakResponse, len(u.Streaks))
+		for i := range u.Streaks {
+			resp.Streaks[i] = u.Streaks[i].ToResponse()
+		}
+	}
+
 	return resp
 }
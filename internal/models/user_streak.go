@@ -0,0 +1,45 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// UserStreak is a member's run of consecutive weeks or months with at least
+// one completed club ride. Current is only valid until ExpiresAt; after
+// that the streak has been broken even though the row hasn't been updated.
+type UserStreak struct {
+	ID         uuid.UUID  `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	UserID     uuid.UUID  `gorm:"type:uuid;not null;uniqueIndex:idx_user_streak_period" json:"user_id"`
+	Period     string     `gorm:"size:20;not null;uniqueIndex:idx_user_streak_period" json:"period"`
+	Current    int        `gorm:"default:0" json:"current"`
+	Longest    int        `gorm:"default:0" json:"longest"`
+	LastPeriod *time.Time `json:"last_period"`
+	ExpiresAt  *time.Time `gorm:"index" json:"expires_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+}
+
+type UserStreakResponse struct {
+	Period     string     `json:"period"`
+	Current    int        `json:"current"`
+	Longest    int        `json:"longest"`
+	LastPeriod *time.Time `json:"last_period"`
+}
+
+// CurrentAt returns the current streak as of now
+func (s *UserStreak) CurrentAt(now time.Time) int {
+	if s.ExpiresAt == nil || !now.Before(*s.ExpiresAt) {
+		return 0
+	}
+	return s.Current
+}
+
+func (s *UserStreak) ToResponse() UserStreakResponse {
+	return UserStreakResponse{
+		Period:     s.Period,
+		Current:    s.CurrentAt(time.Now()),
+		Longest:    s.Longest,
+		LastPeriod: s.LastPeriod,
+	}
+}
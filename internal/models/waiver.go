@@ -0,0 +1,87 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Waiver is a document riders must accept before registering. General
+// waivers apply to every ride; others only to the ride types that require
+// them. The text lives in versions so past acceptances keep pointing at
+// what the rider actually agreed to.
+type Waiver struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	Slug      string    `gorm:"uniqueIndex;size:100;not null" json:"slug"`
+	Title     string    `gorm:"size:255;not null" json:"title"`
+	IsGeneral bool      `gorm:"default:false" json:"is_general"`
+	IsActive  bool      `json:"is_active"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	Versions []WaiverVersion `gorm:"foreignKey:WaiverID" json:"-"`
+}
+
+// WaiverVersion is one revision of a waiver's text. A version is a draft
+// until published; the latest published version is the one riders accept.
+type WaiverVersion struct {
+	ID          uint       `gorm:"primaryKey" json:"id"`
+	WaiverID    uint       `gorm:"not null;uniqueIndex:idx_waiver_version" json:"waiver_id"`
+	Version     int        `gorm:"not null;uniqueIndex:idx_waiver_version" json:"version"`
+	Body        string     `gorm:"type:text;not null" json:"body"`
+	PublishedAt *time.Time `json:"published_at"`
+	CreatedByID uuid.UUID  `gorm:"type:uuid;not null" json:"created_by_id"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+// WaiverAcceptance records that a user accepted a specific waiver version.
+type WaiverAcceptance struct {
+	ID              uuid.UUID     `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	UserID          uuid.UUID     `gorm:"type:uuid;not null;uniqueIndex:idx_acceptance_user_version" json:"user_id"`
+	WaiverVersionID uint          `gorm:"not null;uniqueIndex:idx_acceptance_user_version" json:"waiver_version_id"`
+	WaiverVersion   WaiverVersion `gorm:"foreignKey:WaiverVersionID" json:"-"`
+	WaiverID        uint          `gorm:"not null;index" json:"waiver_id"`
+	Version         int           `gorm:"not null" json:"version"`
+	IPAddress       string        `gorm:"size:45" json:"ip_address"`
+	UserAgent       string        `gorm:"size:500" json:"user_agent"`
+	AcceptedAt      time.Time     `gorm:"not null" json:"accepted_at"`
+}
+
+// RideTypeWaiver marks a waiver as required for rides of a type.
+type RideTypeWaiver struct {
+	RideTypeID uint `gorm:"primaryKey" json:"ride_type_id"`
+	WaiverID   uint `gorm:"primaryKey" json:"waiver_id"`
+}
+
+type WaiverResponse struct {
+	ID             uint       `json:"id"`
+	Slug           string     `json:"slug"`
+	Title          string     `json:"title"`
+	IsGeneral      bool       `json:"is_general"`
+	IsActive       bool       `json:"is_active"`
+	CurrentVersion int        `json:"current_version"`
+	Body           string     `json:"body,omitempty"`
+	PublishedAt    *time.Time `json:"published_at"`
+}
+
+// ToResponse builds the response for the given current version, which may
+// be nil if the waiver has not been published yet.
+func (w *Waiver) ToResponse(current *WaiverVersion, includeBody bool) WaiverResponse {
+	resp := WaiverResponse{
+		ID:        w.ID,
+		Slug:      w.Slug,
+		Title:     w.Title,
+		IsGeneral: w.IsGeneral,
+		IsActive:  w.IsActive,
+	}
+
+	if current != nil {
+		resp.CurrentVersion = current.Version
+		resp.PublishedAt = current.PublishedAt
+		if includeBody {
+			resp.Body = current.Body
+		}
+	}
+
+	return resp
+}
@@ -42,20 +42,35 @@ func Setup(app *fiber.App) {
 	auth.Post("/refresh", handlers.RefreshToken)
 	auth.Get("/me", middleware.AuthRequired(), handlers.GetMe)
 	auth.Put("/me", middleware.AuthRequired(), handlers.UpdateMe)
+	auth.Get("/me/emergency-contact", middleware.AuthRequired(), handlers.GetMyEmergencyContact)
+	auth.Put("/me/emergency-contact", middleware.AuthRequired(), handlers.UpdateMyEmergencyContact)
+	auth.Get("/me/calendar", middleware.AuthRequired(), handlers.GetMyCalendar)
+	auth.Post("/me/calendar/rotate", middleware.AuthRequired(), handlers.RotateMyCalendar)
 	auth.Post("/change-password", middleware.AuthRequired(), handlers.ChangePassword)
 
 	users := api.Group("/users")
 	users.Get("/", handlers.ListUsers)
-	users.Get("/leaderboard", handlers.GetLeaderboard)
+	users.Get("/leaderboard", middleware.OptionalAuth(), handlers.GetLeaderboard)
+	users.Get("/leaderboard/streaks", middleware.OptionalAuth(), handlers.GetStreakLeaderboard)
 	users.Get("/statistics", handlers.GetStatistics)
 	users.Get("/ride-leaders", handlers.GetRideLeaders)
 	users.Get("/:id", handlers.GetUser)
 	users.Get("/:id/rides", handlers.GetUserRides)
+	users.Get("/:id/badges", handlers.GetUserBadges)
+	users.Get("/:id/stats", middleware.OptionalAuth(), handlers.GetUserStats)
+	users.Get("/:id/credits", middleware.AuthRequired(), handlers.ListUserCredits)
+	users.Post("/:id/credits", middleware.AuthRequired(), middleware.AdminRequired(), handlers.AdjustUserCredits)
 	users.Put("/:id/role", middleware.AuthRequired(), middleware.AdminRequired(), handlers.UpdateUserRole)
 
 	rides := api.Group("/rides")
-	rides.Get("/", handlers.ListRides)
+	rides.Get("/", middleware.OptionalAuth(), handlers.ListRides)
 	rides.Get("/types", handlers.GetRideTypes)
+	rides.Put("/types/:id", middleware.AuthRequired(), middleware.AdminRequired(), handlers.UpdateRideType)
+	rides.Put("/types/:id/eligibility", middleware.AuthRequired(), middleware.AdminRequired(), handlers.SetRideTypeEligibility)
+	rides.Put("/types/:id/waivers", middleware.AuthRequired(), middleware.AdminRequired(), handlers.SetRideTypeWaivers)
+	rides.Get("/difficulty-weights", handlers.GetDifficultyWeights)
+	rides.Put("/difficulty-weights", middleware.AuthRequired(), middleware.AdminRequired(), handlers.UpdateDifficultyWeights)
+	rides.Get("/missing-reports", middleware.AuthRequired(), middleware.AdminRequired(), handlers.ListRidesMissingReports)
 	rides.Post("/parse-gpx", middleware.AuthRequired(), middleware.RideLeaderRequired(), handlers.ParseGPXPreview)
 	rides.Get("/:id", handlers.GetRide)
 	rides.Post("/", middleware.AuthRequired(), middleware.RideLeaderRequired(), handlers.CreateRide)
@@ -66,11 +81,93 @@ func Setup(app *fiber.App) {
 	rides.Post("/:id/publish", middleware.AuthRequired(), middleware.RideLeaderRequired(), handlers.PublishRide)
 	rides.Post("/:id/start", middleware.AuthRequired(), middleware.RideLeaderRequired(), handlers.StartRide)
 	rides.Post("/:id/complete", middleware.AuthRequired(), middleware.RideLeaderRequired(), handlers.CompleteRide)
+	rides.Post("/:id/reopen", middleware.AuthRequired(), middleware.AdminRequired(), handlers.ReopenRide)
+	rides.Get("/:id/audit", middleware.AuthRequired(), middleware.AdminRequired(), handlers.GetRideAuditLog)
+	rides.Post("/:id/cancel", middleware.AuthRequired(), middleware.RideLeaderRequired(), handlers.CancelRide)
+	rides.Post("/:id/clone", middleware.AuthRequired(), middleware.RideLeaderRequired(), handlers.CloneRide)
+	rides.Post("/:id/review", middleware.AuthRequired(), middleware.RideLeaderRequired(), handlers.ReviewRide)
+	rides.Get("/:id/report", handlers.GetRideReport)
+	rides.Put("/:id/report", middleware.AuthRequired(), handlers.SubmitRideReport)
 
 	rides.Post("/:id/register", middleware.AuthRequired(), handlers.RegisterForRide)
 	rides.Delete("/:id/register", middleware.AuthRequired(), handlers.UnregisterFromRide)
+	rides.Put("/:id/register/group", middleware.AuthRequired(), handlers.ChooseGroup)
+	rides.Get("/:id/register/payment", middleware.AuthRequired(), handlers.GetMyPayment)
+	rides.Post("/:id/register/pay", middleware.AuthRequired(), handlers.PayForRide)
 	rides.Get("/:id/participants", handlers.ListParticipants)
-	rides.Put("/:id/participants/:pid", middleware.AuthRequired(), middleware.RideLeaderRequired(), handlers.UpdateParticipant)
-	rides.Post("/:id/participants/:pid/attendance", middleware.AuthRequired(), middleware.RideLeaderRequired(), handlers.MarkAttendance)
-	rides.Post("/:id/participants/bulk-attendance", middleware.AuthRequired(), middleware.RideLeaderRequired(), handlers.BulkAttendance)
+	rides.Put("/:id/participants/:pid", middleware.AuthRequired(), handlers.UpdateParticipant)
+	rides.Post("/:id/participants/:pid/attendance", middleware.AuthRequired(), handlers.MarkAttendance)
+	rides.Post("/:id/participants/bulk-attendance", middleware.AuthRequired(), handlers.BulkAttendance)
+	rides.Get("/:id/payments", middleware.AuthRequired(), handlers.ListPayments)
+	rides.Put("/:id/participants/:pid/payment", middleware.AuthRequired(), handlers.RecordPayment)
+	rides.Get("/:id/emergency-contacts", middleware.AuthRequired(), handlers.ListEmergencyContacts)
+
+	rides.Get("/:id/incidents", middleware.AuthRequired(), handlers.ListRideIncidents)
+	rides.Post("/:id/incidents", middleware.AuthRequired(), handlers.CreateIncident)
+	rides.Put("/:id/incidents/:iid", middleware.AuthRequired(), handlers.UpdateIncident)
+
+	rides.Get("/:id/eligibility", middleware.OptionalAuth(), handlers.GetEligibility)
+	rides.Put("/:id/eligibility", middleware.AuthRequired(), handlers.SetRideEligibility)
+	rides.Delete("/:id/eligibility", middleware.AuthRequired(), handlers.DeleteRideEligibility)
+	rides.Post("/:id/eligibility/overrides", middleware.AuthRequired(), handlers.GrantEligibilityOverride)
+	rides.Delete("/:id/eligibility/overrides/:uid", middleware.AuthRequired(), handlers.RevokeEligibilityOverride)
+
+	rides.Get("/:id/comments", middleware.OptionalAuth(), handlers.ListComments)
+	rides.Post("/:id/comments", middleware.AuthRequired(), handlers.CreateComment)
+	rides.Put("/:id/comments/:cid", middleware.AuthRequired(), handlers.UpdateComment)
+	rides.Delete("/:id/comments/:cid", middleware.AuthRequired(), handlers.DeleteComment)
+
+	rides.Get("/:id/photos", middleware.OptionalAuth(), handlers.ListPhotos)
+	rides.Post("/:id/photos", middleware.AuthRequired(), handlers.UploadPhoto)
+	rides.Get("/:id/photos/:pid/image", handlers.GetPhotoImage)
+	rides.Get("/:id/photos/:pid/thumbnail", handlers.GetPhotoThumbnail)
+	rides.Delete("/:id/photos/:pid", middleware.AuthRequired(), handlers.DeletePhoto)
+
+	rides.Get("/:id/check-in-code", middleware.AuthRequired(), handlers.GetCheckInCode)
+	rides.Post("/:id/check-in-code/rotate", middleware.AuthRequired(), handlers.RotateCheckInCode)
+	rides.Post("/:id/check-in", middleware.AuthRequired(), handlers.CheckIn)
+	rides.Post("/:id/check-in/location", middleware.AuthRequired(), handlers.LocationCheckIn)
+
+	rides.Get("/:id/positions", middleware.AuthRequired(), handlers.GetLivePositions)
+	rides.Post("/:id/positions", middleware.AuthRequired(), handlers.PostPosition)
+	rides.Put("/:id/positions/retention", middleware.AuthRequired(), handlers.SetPositionRetention)
+	rides.Get("/:id/live", middleware.AuthRequired(), handlers.StreamLivePositions)
+
+	rides.Get("/:id/groups", handlers.ListGroups)
+	rides.Post("/:id/groups", middleware.AuthRequired(), handlers.CreateGroup)
+	rides.Put("/:id/groups/:gid", middleware.AuthRequired(), handlers.UpdateGroup)
+	rides.Delete("/:id/groups/:gid", middleware.AuthRequired(), handlers.DeleteGroup)
+
+	rides.Get("/:id/staff", handlers.ListStaff)
+	rides.Post("/:id/staff", middleware.AuthRequired(), middleware.RideLeaderRequired(), handlers.AssignStaff)
+	rides.Delete("/:id/staff/:uid", middleware.AuthRequired(), middleware.RideLeaderRequired(), handlers.RemoveStaff)
+
+	calendar := api.Group("/calendar")
+	calendar.Get("/club.ics", handlers.ClubCalendar)
+	calendar.Get("/users/:token.ics", handlers.UserCalendar)
+
+	feeds := api.Group("/feeds")
+	feeds.Get("/rides.atom", handlers.RidesFeed)
+
+	waivers := api.Group("/waivers")
+	waivers.Get("/", middleware.OptionalAuth(), handlers.ListWaivers)
+	waivers.Get("/pending", middleware.AuthRequired(), handlers.GetPendingWaivers)
+	waivers.Get("/:id", handlers.GetWaiver)
+	waivers.Post("/:id/accept", middleware.AuthRequired(), handlers.AcceptWaiver)
+	waivers.Post("/", middleware.AuthRequired(), middleware.AdminRequired(), handlers.CreateWaiver)
+	waivers.Put("/:id", middleware.AuthRequired(), middleware.AdminRequired(), handlers.UpdateWaiver)
+	waivers.Get("/:id/versions", middleware.AuthRequired(), middleware.AdminRequired(), handlers.ListWaiverVersions)
+	waivers.Post("/:id/versions", middleware.AuthRequired(), middleware.AdminRequired(), handlers.CreateWaiverVersion)
+	waivers.Post("/:id/versions/:version/publish", middleware.AuthRequired(), middleware.AdminRequired(), handlers.PublishWaiverVersion)
+	waivers.Get("/:id/acceptances", middleware.AuthRequired(), middleware.AdminRequired(), handlers.ListWaiverAcceptances)
+
+	incidents := api.Group("/incidents", middleware.AuthRequired(), middleware.AdminRequired())
+	incidents.Get("/", handlers.ListIncidents)
+	incidents.Get("/export", handlers.ExportIncidents)
+	incidents.Post("/:id/review", handlers.ReviewIncident)
+
+	badges := api.Group("/badges")
+	badges.Get("/", middleware.OptionalAuth(), handlers.ListBadges)
+	badges.Post("/", middleware.AuthRequired(), middleware.AdminRequired(), handlers.CreateBadge)
+	badges.Put("/:id", middleware.AuthRequired(), middleware.AdminRequired(), handlers.UpdateBadge)
 }
@@ -0,0 +1,147 @@
+package scheduler
+
+import (
+	"errors"
+	"log"
+	"time"
+
+	"github.com/udacc/uda-cycling-club/internal/models"
+	"github.com/udacc/uda-cycling-club/internal/services"
+	"gorm.io/gorm"
+)
+
+// Scheduler periodically moves rides through their lifecycle so that rides
+// whose leader forgot to press start/complete don't stay open forever.
+type Scheduler struct {
+	db       *gorm.DB
+	interval time.Duration
+	stop     chan struct{}
+}
+
+func New(db *gorm.DB, interval time.Duration) *Scheduler {
+	return &Scheduler{
+		db:       db,
+		interval: interval,
+		stop:     make(chan struct{}),
+	}
+}
+
+// Start runs the scheduler in a background goroutine until Stop is called.
+func (s *Scheduler) Start() {
+	go func() {
+		ticker := time.NewTicker(s.interval)
+		defer ticker.Stop()
+
+		s.run()
+		for {
+			select {
+			case <-ticker.C:
+				s.run()
+			case <-s.stop:
+				return
+			}
+		}
+	}()
+
+	log.Printf("Ride scheduler started (interval %s)", s.interval)
+}
+
+func (s *Scheduler) Stop() {
+	close(s.stop)
+}
+
+func (s *Scheduler) run() {
+	now := time.Now()
+	s.startDueRides(now)
+	s.completeOverdueRides(now)
+	s.expireUnpaidRegistrations(now)
+}
+
+// startDueRides starts published rides whose start time has passed and whose
+// ride type allows auto-start. The ride creator leads unless a leader was
+// already assigned to the ride or its staff.
+func (s *Scheduler) startDueRides(now time.Time) {
+	var rides []models.Ride
+	s.db.
+		Joins("JOIN ride_types ON ride_types.id = rides.ride_type_id").
+		Where("rides.status = ? AND rides.start_time <= ? AND ride_types.auto_start = ?",
+			models.RideStatusPublished, now, true).
+		Find(&rides)
+
+	for i := range rides {
+		ride := &rides[i]
+
+		leaderID := ride.CreatedByID
+		if staffLeaderID := services.StaffLeaderID(s.db, ride.ID); staffLeaderID != nil {
+			leaderID = *staffLeaderID
+		} else if ride.LeaderID != nil {
+			leaderID = *ride.LeaderID
+		}
+
+		err := services.StartRide(s.db, ride.ID, leaderID)
+		if errors.Is(err, services.ErrRideNotPublished) {
+			// Cancelled or started by its leader since we loaded it
+			continue
+		}
+		if err != nil {
+			log.Printf("Scheduler: failed to start ride %s: %v", ride.ID, err)
+			continue
+		}
+		log.Printf("Scheduler: auto-started ride %s", ride.ID)
+	}
+}
+
+// completeOverdueRides completes ongoing rides that have run past their
+// estimated duration plus the ride type's grace period. Such rides are
+// flagged for leader review since attendance may not have been recorded.
+func (s *Scheduler) completeOverdueRides(now time.Time) {
+	var rides []models.Ride
+	s.db.
+		Preload("RideType").
+		Joins("JOIN ride_types ON ride_types.id = rides.ride_type_id").
+		// Reopened rides wait for the admin correcting them
+		Where("rides.status = ? AND ride_types.auto_complete = ? AND rides.reopened_at IS NULL", models.RideStatusOngoing, true).
+		Find(&rides)
+
+	for i := range rides {
+		ride := &rides[i]
+
+		started := ride.StartedAt
+		if started == nil {
+			started = ride.StartTime
+		}
+		duration := ride.EstimatedDuration()
+		if started == nil || duration == 0 {
+			continue
+		}
+
+		grace := time.Duration(ride.RideType.CompletionGraceMinutes) * time.Minute
+		if now.Before(started.Add(duration + grace)) {
+			continue
+		}
+
+		summary, err := services.CompleteRide(s.db, ride.ID, services.CompleteRideOptions{Auto: true})
+		if errors.Is(err, services.ErrRideNotOngoing) {
+			// Completed by its leader since we loaded it
+			continue
+		}
+		if err != nil {
+			log.Printf("Scheduler: failed to complete ride %s: %v", ride.ID, err)
+			continue
+		}
+		log.Printf("Scheduler: auto-completed ride %s, credited %d participants", ride.ID, summary.CreditedCount)
+	}
+}
+
+// expireUnpaidRegistrations frees the places of riders who didn't pay the
+// ride fee before their payment deadline.
+func (s *Scheduler) expireUnpaidRegistrations(now time.Time) {
+	expired, err := services.ExpireUnpaidRegistrations(s.db, now)
+	if err != nil {
+		log.Printf("Scheduler: failed to expire unpaid registrations: %v", err)
+		return
+	}
+	if expired > 0 {
+		log.Printf("Scheduler: expired %d unpaid registrations", expired)
+	}
+}
@@ -0,0 +1,199 @@
+package services
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/udacc/uda-cycling-club/internal/models"
+	"gorm.io/gorm"
+)
+
+// EvaluateBadges awards the active badges the given users have reached now
+// that the ride is completed, recording the ride as the trigger. Call it
+// inside the completion transaction.
+func EvaluateBadges(db *gorm.DB, ride *models.Ride, userIDs []uuid.UUID) ([]models.UserBadge, error) {
+	var badges []models.Badge
+	if err := db.Where("is_active = ?", true).Find(&badges).Error; err != nil {
+		return nil, err
+	}
+
+	completedAt := time.Now()
+	if ride.CompletedAt != nil {
+		completedAt = *ride.CompletedAt
+	}
+	seasonFrom, seasonTo := SeasonRange(rideDateOf(ride))
+
+	var awarded []models.UserBadge
+	for i := range badges {
+		badge := &badges[i]
+
+		// Rides of other types can't change progress towards this badge
+		if badge.RideTypeID != nil && *badge.RideTypeID != ride.RideTypeID {
+			continue
+		}
+
+		awards, err := awardBadge(db, badge, userIDs, seasonFrom, seasonTo, &ride.ID, completedAt)
+		if err != nil {
+			return nil, err
+		}
+		awarded = append(awarded, awards...)
+	}
+
+	return awarded, nil
+}
+
+// BackfillBadges evaluates every active badge for every user, so badges
+// added after members rode count their earlier rides. Season badges are
+// evaluated for each season in which completed rides were ridden. It
+// returns the number of badges awarded.
+func BackfillBadges(db *gorm.DB) (int, error) {
+	var badges []models.Badge
+	if err := db.Where("is_active = ?", true).Find(&badges).Error; err != nil {
+		return 0, err
+	}
+
+	var userIDs []uuid.UUID
+	if err := db.Model(&models.User{}).Pluck("id", &userIDs).Error; err != nil {
+		return 0, err
+	}
+
+	var riddenAt []time.Time
+	if err := db.Model(&models.Ride{}).
+		Where("status = ?", models.RideStatusCompleted).
+		Pluck(RideDate, &riddenAt).Error; err != nil {
+		return 0, err
+	}
+
+	seasons := make(map[time.Time]time.Time)
+	for _, t := range riddenAt {
+		from, to := SeasonRange(t)
+		seasons[from] = to
+	}
+
+	now := time.Now()
+	awarded := 0
+	err := db.Transaction(func(tx *gorm.DB) error {
+		for i := range badges {
+			badge := &badges[i]
+
+			if badge.Scope != models.BadgeScopeSeason {
+				awards, err := awardBadge(tx, badge, userIDs, time.Time{}, time.Time{}, nil, now)
+				if err != nil {
+					return err
+				}
+				awarded += len(awards)
+				continue
+			}
+
+			for from, to := range seasons {
+				awards, err := awardBadge(tx, badge, userIDs, from, to, nil, now)
+				if err != nil {
+					return err
+				}
+				awarded += len(awards)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return awarded, nil
+}
+
+// awardBadge awards the badge to the users who have reached it and don't
+// hold it yet. Season badges count rides ridden in [seasonFrom, seasonTo).
+func awardBadge(db *gorm.DB, badge *models.Badge, userIDs []uuid.UUID, seasonFrom, seasonTo time.Time, rideID *uuid.UUID, awardedAt time.Time) ([]models.UserBadge, error) {
+	season := ""
+	if badge.Scope == models.BadgeScopeSeason {
+		season = SeasonLabel(seasonFrom, seasonTo)
+	}
+
+	threshold := badge.Threshold
+	if badge.RuleType == models.BadgeRuleRideTypesCompleted && threshold <= 0 {
+		var rideTypes int64
+		if err := db.Model(&models.RideType{}).Count(&rideTypes).Error; err != nil {
+			return nil, err
+		}
+		threshold = float64(rideTypes)
+	}
+
+	var awarded []models.UserBadge
+	for _, userID := range userIDs {
+		var held int64
+		if err := db.Model(&models.UserBadge{}).
+			Where("user_id = ? AND badge_id = ? AND season = ?", userID, badge.ID, season).
+			Count(&held).Error; err != nil {
+			return nil, err
+		}
+		if held > 0 {
+			continue
+		}
+
+		query := badgeProgressQuery(db, badge, userID)
+		if badge.Scope == models.BadgeScopeSeason {
+			query = query.Where(RideDate+" >= ? AND "+RideDate+" < ?", seasonFrom, seasonTo)
+		}
+
+		var progress float64
+		if err := query.Scan(&progress).Error; err != nil {
+			return nil, err
+		}
+		if progress < threshold || threshold <= 0 {
+			continue
+		}
+
+		award := models.UserBadge{
+			UserID:    userID,
+			BadgeID:   badge.ID,
+			Season:    season,
+			RideID:    rideID,
+			AwardedAt: awardedAt,
+		}
+		if err := db.Create(&award).Error; err != nil {
+			return nil, err
+		}
+		award.Badge = *badge
+		awarded = append(awarded, award)
+	}
+
+	return awarded, nil
+}
+
+// badgeProgressQuery selects the user's value for the badge's rule over
+// their completed rides
+func badgeProgressQuery(db *gorm.DB, badge *models.Badge, userID uuid.UUID) *gorm.DB {
+	var query *gorm.DB
+
+	if badge.RuleType == models.BadgeRuleRidesLed {
+		query = db.Table("rides").
+			Select("COUNT(*)").
+			Where("rides.leader_id = ? AND rides.status = ?", userID, models.RideStatusCompleted)
+	} else {
+		value := map[models.BadgeRuleType]string{
+			models.BadgeRuleSingleRideDistance: "COALESCE(MAX(ride_participants.final_distance_km), 0)",
+			models.BadgeRuleTotalDistance:      "COALESCE(SUM(ride_participants.final_distance_km), 0)",
+			models.BadgeRuleRideCount:          "COUNT(*)",
+			models.BadgeRulePassesClimbed:      "COALESCE(SUM(rides.pass_count), 0)",
+			models.BadgeRuleRideTypesCompleted: "COUNT(DISTINCT rides.ride_type_id)",
+		}[badge.RuleType]
+
+		query = db.Table("ride_participants").
+			Select(value).
+			Joins("JOIN rides ON rides.id = ride_participants.ride_id").
+			Where("ride_participants.user_id = ? AND ride_participants.completed = ? AND rides.status = ?",
+				userID, true, models.RideStatusCompleted)
+	}
+
+	if badge.RideTypeID != nil {
+		query = query.Where("rides.ride_type_id = ?", *badge.RideTypeID)
+	}
+	return query
+}
+
+// RevokeRideBadges removes the badges a ride's completion awarded, so they
+// are re-evaluated when the ride is completed again.
+func RevokeRideBadges(db *gorm.DB, rideID uuid.UUID) error {
+	return db.Where("ride_id = ?", rideID).Delete(&models.UserBadge{}).Error
+}
@@ -0,0 +1,67 @@
+package services
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/hex"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/udacc/uda-cycling-club/internal/config"
+	"github.com/udacc/uda-cycling-club/internal/models"
+)
+
+const checkInCodeLength = 8
+
+// NewCheckInNonce returns a random nonce. Replacing a ride's nonce
+// invalidates every check-in code issued for it so far.
+func NewCheckInNonce() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// CheckInCode returns the ride's check-in code for the time window
+// containing t, along with the moment the code expires. Codes are derived
+// from the ride ID, its nonce and the window with an HMAC keyed by the
+// server secret, so they need no storage.
+func CheckInCode(ride *models.Ride, t time.Time) (string, time.Time) {
+	ttl := checkInCodeTTL()
+	window := t.Unix() / int64(ttl.Seconds())
+	expiresAt := time.Unix((window+1)*int64(ttl.Seconds()), 0)
+	return checkInCodeForWindow(ride, window), expiresAt
+}
+
+// VerifyCheckInCode accepts the code of the current window and of the one
+// before it, so a code scanned just before rotation still works.
+func VerifyCheckInCode(ride *models.Ride, code string, now time.Time) bool {
+	if ride.CheckInNonce == "" {
+		return false
+	}
+
+	code = strings.ToUpper(strings.TrimSpace(code))
+	window := now.Unix() / int64(checkInCodeTTL().Seconds())
+	for _, w := range []int64{window, window - 1} {
+		if hmac.Equal([]byte(code), []byte(checkInCodeForWindow(ride, w))) {
+			return true
+		}
+	}
+	return false
+}
+
+func checkInCodeForWindow(ride *models.Ride, window int64) string {
+	mac := hmac.New(sha256.New, []byte(config.AppConfig.JWTSecret))
+	mac.Write([]byte(ride.ID.String() + ":" + ride.CheckInNonce + ":" + strconv.FormatInt(window, 10)))
+	return base32.StdEncoding.EncodeToString(mac.Sum(nil))[:checkInCodeLength]
+}
+
+func checkInCodeTTL() time.Duration {
+	ttl := time.Duration(config.AppConfig.CheckInCodeTTL) * time.Second
+	if ttl < time.Second {
+		ttl = time.Minute
+	}
+	return ttl
+}
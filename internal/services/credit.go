@@ -0,0 +1,145 @@
+package services
+
+import (
+	"math"
+
+	"github.com/google/uuid"
+	"github.com/udacc/uda-cycling-club/internal/models"
+	"gorm.io/gorm"
+)
+
+// RecordCredit appends a ledger entry and applies it to the user's totals.
+// Call it inside the transaction that causes the change.
+func RecordCredit(db *gorm.DB, entry *models.CreditLedgerEntry) error {
+	if err := db.Create(entry).Error; err != nil {
+		return err
+	}
+
+	return db.Model(&models.User{}).
+		Where("id = ?", entry.UserID).
+		Updates(map[string]interface{}{
+			"total_distance_km": gorm.Expr("total_distance_km + ?", entry.DistanceKm),
+			"total_rides":       gorm.Expr("total_rides + ?", entry.RideCount),
+		}).Error
+}
+
+// CorrectParticipantCredit records the change in what a participant of a
+// completed ride is credited after staff edit their attendance, distance or
+// bonus. before is the participant row as locked in the transaction; after
+// carries the edits and has Completed and FinalDistanceKm updated to match.
+func CorrectParticipantCredit(db *gorm.DB, ride *models.Ride, before models.RideParticipant, after *models.RideParticipant, actorID uuid.UUID, reason string) error {
+	after.Completed = ride.Status == models.RideStatusCompleted && after.Attended
+
+	var distance float64
+	var count int
+	if before.Completed {
+		distance -= before.FinalDistanceKm
+		count--
+	}
+	if after.Completed {
+		distance += after.FinalDistanceKm
+		count++
+	}
+
+	if math.Abs(distance) < 0.005 && count == 0 {
+		return nil
+	}
+
+	if err := RecordCredit(db, &models.CreditLedgerEntry{
+		UserID:     after.UserID,
+		RideID:     &ride.ID,
+		DistanceKm: distance,
+		RideCount:  count,
+		Reason:     models.CreditReasonParticipantCorrected,
+		Note:       reason,
+		ActorID:    &actorID,
+	}); err != nil {
+		return err
+	}
+
+	return db.Create(&models.AuditLog{
+		Action:        models.AuditActionCreditCorrected,
+		ActorID:       &actorID,
+		RideID:        &ride.ID,
+		SubjectUserID: &after.UserID,
+		DistanceKm:    distance,
+		RideCount:     count,
+		Reason:        reason,
+	}).Error
+}
+
+type creditTotals struct {
+	UserID     uuid.UUID
+	DistanceKm float64
+	RideCount  int
+}
+
+// RecomputeTotals rebuilds every user's totals. Ride credits in the ledger
+// are first reconciled against completed ride_participants rows, with any
+// difference appended as a reconciliation entry, then the user counters are
+// set to the ledger sums. It returns the number of reconciled users.
+func RecomputeTotals(db *gorm.DB) (int, error) {
+	reconciled := 0
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var expected []creditTotals
+		if err := tx.Model(&models.RideParticipant{}).
+			Select("user_id, COALESCE(SUM(final_distance_km), 0) AS distance_km, COUNT(*) AS ride_count").
+			Where("completed = ?", true).
+			Group("user_id").
+			Scan(&expected).Error; err != nil {
+			return err
+		}
+
+		var recorded []creditTotals
+		if err := tx.Model(&models.CreditLedgerEntry{}).
+			Select("user_id, COALESCE(SUM(distance_km), 0) AS distance_km, COALESCE(SUM(ride_count), 0) AS ride_count").
+			Where("reason != ?", models.CreditReasonManualAdjustment).
+			Group("user_id").
+			Scan(&recorded).Error; err != nil {
+			return err
+		}
+
+		diffs := make(map[uuid.UUID]*creditTotals)
+		for _, t := range expected {
+			diffs[t.UserID] = &creditTotals{UserID: t.UserID, DistanceKm: t.DistanceKm, RideCount: t.RideCount}
+		}
+		for _, t := range recorded {
+			d, ok := diffs[t.UserID]
+			if !ok {
+				d = &creditTotals{UserID: t.UserID}
+				diffs[t.UserID] = d
+			}
+			d.DistanceKm -= t.DistanceKm
+			d.RideCount -= t.RideCount
+		}
+
+		for _, d := range diffs {
+			if math.Abs(d.DistanceKm) < 0.005 && d.RideCount == 0 {
+				continue
+			}
+			if err := tx.Create(&models.CreditLedgerEntry{
+				UserID:     d.UserID,
+				DistanceKm: d.DistanceKm,
+				RideCount:  d.RideCount,
+				Reason:     models.CreditReasonReconciliation,
+				Note:       "Recomputed from ride participation",
+			}).Error; err != nil {
+				return err
+			}
+			reconciled++
+		}
+
+		return tx.Session(&gorm.Session{AllowGlobalUpdate: true}).
+			Model(&models.User{}).
+			Updates(map[string]interface{}{
+				"total_distance_km": gorm.Expr("COALESCE((SELECT SUM(distance_km) FROM credit_ledger_entries WHERE credit_ledger_entries.user_id = users.id), 0)"),
+				"total_rides":       gorm.Expr("COALESCE((SELECT SUM(ride_count) FROM credit_ledger_entries WHERE credit_ledger_entries.user_id = users.id), 0)"),
+			}).Error
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return reconciled, nil
+}
@@ -0,0 +1,72 @@
+package services
+
+import (
+	"github.com/udacc/uda-cycling-club/internal/models"
+	"github.com/udacc/uda-cycling-club/pkg/gpx"
+	"gorm.io/gorm"
+)
+
+// DifficultyWeights returns the admin-configured difficulty weights, or the
+// defaults if none were saved yet.
+func DifficultyWeights(db *gorm.DB) gpx.DifficultyWeights {
+	var settings models.DifficultySettings
+	if err := db.First(&settings, models.DifficultySettingsID).Error; err != nil {
+		return gpx.DefaultDifficultyWeights
+	}
+	return settings.Weights
+}
+
+// ApplyDifficulty sets the ride's difficulty score and level from its route
+// stats. It does not save the ride.
+func ApplyDifficulty(ride *models.Ride, weights gpx.DifficultyWeights) {
+	ride.DifficultyScore, ride.DifficultyLevel = gpx.CalculateDifficulty(
+		ride.DistanceKm, ride.ElevationGain, ride.MaxGradient, ride.PassCount, weights)
+}
+
+// SaveDifficultyWeights stores new weights and rescores every ride with them.
+func SaveDifficultyWeights(db *gorm.DB, weights gpx.DifficultyWeights) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		settings := models.DifficultySettings{
+			ID:      models.DifficultySettingsID,
+			Weights: weights,
+		}
+		if err := tx.Save(&settings).Error; err != nil {
+			return err
+		}
+
+		_, err := rescoreRides(tx, tx, weights)
+		return err
+	})
+}
+
+// RescoreUnscoredRides scores rides saved before difficulty was calculated,
+// which still have a zero score. It returns the number of rides rescored.
+func RescoreUnscoredRides(db *gorm.DB) (int, error) {
+	var rescored int
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		rescored, err = rescoreRides(tx, tx.Where("difficulty_score = ?", 0), DifficultyWeights(tx))
+		return err
+	})
+	return rescored, err
+}
+
+// rescoreRides recalculates the difficulty of the rides the query selects
+func rescoreRides(db *gorm.DB, query *gorm.DB, weights gpx.DifficultyWeights) (int, error) {
+	var rides []models.Ride
+	if err := query.Find(&rides).Error; err != nil {
+		return 0, err
+	}
+
+	for i := range rides {
+		ApplyDifficulty(&rides[i], weights)
+		if err := db.Model(&rides[i]).UpdateColumns(map[string]interface{}{
+			"difficulty_score": rides[i].DifficultyScore,
+			"difficulty_level": rides[i].DifficultyLevel,
+		}).Error; err != nil {
+			return 0, err
+		}
+	}
+
+	return len(rides), nil
+}
@@ -0,0 +1,88 @@
+package services
+
+import (
+	"fmt"
+
+	"github.com/udacc/uda-cycling-club/internal/models"
+	"gorm.io/gorm"
+)
+
+// CheckEligibility evaluates the ride's own rule and its ride type's rule
+// against the user and returns a human readable reason for every criterion
+// that is not met. An empty result means the user may register. A leader
+// granted override skips all rules.
+func CheckEligibility(db *gorm.DB, ride *models.Ride, user *models.User) []string {
+	var override models.EligibilityOverride
+	if err := db.Where("ride_id = ? AND user_id = ?", ride.ID, user.ID).First(&override).Error; err == nil {
+		return nil
+	}
+
+	var rules []models.EligibilityRule
+	db.Where("ride_id = ? OR ride_type_id = ?", ride.ID, ride.RideTypeID).Find(&rules)
+
+	var reasons []string
+	for _, rule := range rules {
+		reasons = append(reasons, evaluateRule(db, &rule, user)...)
+	}
+
+	return reasons
+}
+
+func evaluateRule(db *gorm.DB, rule *models.EligibilityRule, user *models.User) []string {
+	var reasons []string
+
+	if rule.MinTotalDistanceKm > 0 && user.TotalDistanceKm < rule.MinTotalDistanceKm {
+		reasons = append(reasons, fmt.Sprintf(
+			"At least %.0f km total club distance is required (you have %.0f km)",
+			rule.MinTotalDistanceKm, user.TotalDistanceKm))
+	}
+
+	if rule.MinCompletedRides > 0 {
+		query := db.Model(&models.RideParticipant{}).
+			Joins("JOIN rides ON rides.id = ride_participants.ride_id").
+			Where("ride_participants.user_id = ? AND ride_participants.completed = ? AND rides.status = ?",
+				user.ID, true, models.RideStatusCompleted)
+
+		rideTypeName := ""
+		if rule.MinCompletedRidesTypeID != nil {
+			query = query.Where("rides.ride_type_id = ?", *rule.MinCompletedRidesTypeID)
+
+			var rideType models.RideType
+			if err := db.First(&rideType, *rule.MinCompletedRidesTypeID).Error; err == nil {
+				rideTypeName = " of type \"" + rideType.Name + "\""
+			}
+		}
+
+		var completed int64
+		query.Count(&completed)
+
+		if int(completed) < rule.MinCompletedRides {
+			reasons = append(reasons, fmt.Sprintf(
+				"At least %d completed rides%s are required (you have %d)",
+				rule.MinCompletedRides, rideTypeName, completed))
+		}
+	}
+
+	if rule.RequiredMembershipStatus != "" && user.MembershipStatus != rule.RequiredMembershipStatus {
+		reasons = append(reasons, fmt.Sprintf(
+			"Membership status \"%s\" is required", rule.RequiredMembershipStatus))
+	}
+
+	if rule.RequiredRideID != nil {
+		var count int64
+		db.Model(&models.RideParticipant{}).
+			Where("ride_id = ? AND user_id = ? AND completed = ?", *rule.RequiredRideID, user.ID, true).
+			Count(&count)
+
+		if count == 0 {
+			var required models.Ride
+			title := rule.RequiredRideID.String()
+			if err := db.First(&required, "id = ?", *rule.RequiredRideID).Error; err == nil {
+				title = required.Title
+			}
+			reasons = append(reasons, fmt.Sprintf("Completing the ride \"%s\" is required", title))
+		}
+	}
+
+	return reasons
+}
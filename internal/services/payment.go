@@ -0,0 +1,43 @@
+package services
+
+import (
+	"time"
+
+	"github.com/udacc/uda-cycling-club/internal/models"
+	"github.com/udacc/uda-cycling-club/pkg/payment"
+	"gorm.io/gorm"
+)
+
+// Payments is the provider used for online payments. It is set at startup.
+var Payments payment.Provider
+
+// SyncPaymentRequirement brings existing registrations in line with the
+// ride's fee after it changes: riders who registered for free now owe the
+// fee, and pending payments are dropped when the fee is removed.
+func SyncPaymentRequirement(db *gorm.DB, ride *models.Ride) error {
+	if !ride.HasFee() {
+		return db.Model(&models.RideParticipant{}).
+			Where("ride_id = ? AND payment_status = ?", ride.ID, models.PaymentStatusPending).
+			Updates(map[string]interface{}{
+				"payment_status": models.PaymentStatusNotRequired,
+				"payment_due_at": nil,
+			}).Error
+	}
+
+	return db.Model(&models.RideParticipant{}).
+		Where("ride_id = ? AND payment_status = ?", ride.ID, models.PaymentStatusNotRequired).
+		Updates(map[string]interface{}{
+			"payment_status": models.PaymentStatusPending,
+			"payment_due_at": ride.PaymentDueAt(time.Now()),
+		}).Error
+}
+
+// ExpireUnpaidRegistrations removes registrations for upcoming rides whose
+// payment deadline has passed. It returns how many were removed.
+func ExpireUnpaidRegistrations(db *gorm.DB, now time.Time) (int64, error) {
+	result := db.
+		Where("payment_status = ? AND payment_due_at < ?", models.PaymentStatusPending, now).
+		Where("ride_id IN (?)", db.Model(&models.Ride{}).Select("id").Where("status = ?", models.RideStatusPublished)).
+		Delete(&models.RideParticipant{})
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,443 @@
+package services
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/udacc/uda-cycling-club/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// StartRide moves a published ride to ongoing under the given leader and
+// records the leader in the ride's staff. It returns ErrRideNotPublished if
+// the ride was cancelled, started or otherwise changed status meanwhile.
+func StartRide(db *gorm.DB, rideID, leaderID uuid.UUID) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		var ride models.Ride
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&ride, "id = ?", rideID).Error; err != nil {
+			return err
+		}
+		if ride.Status != models.RideStatusPublished {
+			return ErrRideNotPublished
+		}
+
+		if err := tx.Model(&ride).Updates(map[string]interface{}{
+			"status":     models.RideStatusOngoing,
+			"leader_id":  leaderID,
+			"started_at": time.Now(),
+		}).Error; err != nil {
+			return err
+		}
+
+		return setStaffLeader(tx, ride.ID, leaderID)
+	})
+}
+
+// SetRideLeader makes leaderID the ride's staff leader, demoting any
+// previous leader to co-leader. An ongoing ride's leader is changed too.
+func SetRideLeader(db *gorm.DB, ride *models.Ride, leaderID, assignedByID uuid.UUID) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := setStaffLeader(tx, ride.ID, leaderID); err != nil {
+			return err
+		}
+
+		if err := tx.Model(&models.RideStaff{}).
+			Where("ride_id = ? AND user_id = ?", ride.ID, leaderID).
+			Update("assigned_by_id", assignedByID).Error; err != nil {
+			return err
+		}
+
+		if ride.Status != models.RideStatusOngoing {
+			return nil
+		}
+		ride.LeaderID = &leaderID
+		return tx.Model(ride).Update("leader_id", leaderID).Error
+	})
+}
+
+// StaffLeaderID returns the user assigned the leader role in the ride's
+// staff, if any.
+func StaffLeaderID(db *gorm.DB, rideID uuid.UUID) *uuid.UUID {
+	var staff models.RideStaff
+	if err := db.Where("ride_id = ? AND role = ?", rideID, models.StaffRoleLeader).First(&staff).Error; err != nil {
+		return nil
+	}
+	return &staff.UserID
+}
+
+// setStaffLeader makes leaderID the ride's only staff leader, demoting any
+// previous leader to co-leader.
+func setStaffLeader(db *gorm.DB, rideID, leaderID uuid.UUID) error {
+	if err := db.Model(&models.RideStaff{}).
+		Where("ride_id = ? AND role = ? AND user_id <> ?", rideID, models.StaffRoleLeader, leaderID).
+		Update("role", models.StaffRoleCoLeader).Error; err != nil {
+		return err
+	}
+
+	var staff models.RideStaff
+	if err := db.Where("ride_id = ? AND user_id = ?", rideID, leaderID).First(&staff).Error; err == nil {
+		staff.Role = models.StaffRoleLeader
+		return db.Save(&staff).Error
+	}
+
+	return db.Create(&models.RideStaff{
+		RideID: rideID,
+		UserID: leaderID,
+		Role:   models.StaffRoleLeader,
+	}).Error
+}
+
+var (
+	ErrRideNotPublished       = errors.New("ride is not published")
+	ErrRideNotOngoing         = errors.New("ride is not ongoing")
+	ErrRideNotCompleted       = errors.New("ride is not completed")
+	ErrIdempotencyKeyConflict = errors.New("idempotency key was used for another ride")
+)
+
+// CompleteRideOptions holds the optional parts of a ride completion.
+// Report, if set, is saved as the ride's report in the same transaction.
+type CompleteRideOptions struct {
+	BonusPercentage *float64
+	IdempotencyKey  string
+	ActorID         *uuid.UUID
+	Auto            bool
+	Report          *models.RideReport
+}
+
+// CompleteRide marks an ongoing ride completed and credits every attended
+// participant with their final distance. Everything runs in one
+// transaction holding a lock on the ride row, so concurrent calls can't
+// credit anyone twice and a failure part way leaves nothing credited. A
+// repeated idempotency key returns the summary of the original completion.
+func CompleteRide(db *gorm.DB, rideID uuid.UUID, opts CompleteRideOptions) (*models.RideCompletionSummary, error) {
+	var summary *models.RideCompletionSummary
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var ride models.Ride
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&ride, "id = ?", rideID).Error; err != nil {
+			return err
+		}
+
+		if opts.IdempotencyKey != "" {
+			var previous models.RideCompletion
+			err := tx.Where("idempotency_key = ?", opts.IdempotencyKey).First(&previous).Error
+			if err == nil {
+				if previous.RideID != rideID {
+					return ErrIdempotencyKeyConflict
+				}
+				summary, err = replayedCompletion(tx, &ride, &previous)
+				return err
+			}
+			if !errors.Is(err, gorm.ErrRecordNotFound) {
+				return err
+			}
+		}
+
+		if ride.Status != models.RideStatusOngoing {
+			return ErrRideNotOngoing
+		}
+
+		if opts.BonusPercentage != nil {
+			ride.BonusPercentage = *opts.BonusPercentage
+		}
+
+		now := time.Now()
+		ride.Status = models.RideStatusCompleted
+		ride.CompletedAt = &now
+		ride.ReopenedAt = nil
+		if opts.Auto {
+			ride.AutoCompleted = true
+			ride.NeedsReview = true
+		}
+
+		if err := tx.Omit(clause.Associations).Save(&ride).Error; err != nil {
+			return err
+		}
+
+		if err := PurgeRidePositions(tx, ride.ID); err != nil {
+			return err
+		}
+
+		var participants []models.RideParticipant
+		if err := tx.Where("ride_id = ? AND attended = ?", ride.ID, true).Find(&participants).Error; err != nil {
+			return err
+		}
+
+		completion := models.RideCompletion{
+			RideID:        ride.ID,
+			ActorID:       opts.ActorID,
+			AutoCompleted: opts.Auto,
+		}
+		if opts.IdempotencyKey != "" {
+			completion.IdempotencyKey = &opts.IdempotencyKey
+		}
+		// Created first so the credits and badges can point at it
+		if err := tx.Create(&completion).Error; err != nil {
+			return err
+		}
+
+		credited := make([]models.CreditedParticipant, 0, len(participants))
+		for i := range participants {
+			participant := &participants[i]
+			participant.Completed = true
+			participant.CalculateFinalDistance(ride.DistanceKm, ride.BonusPercentage)
+
+			if err := tx.Model(participant).Updates(map[string]interface{}{
+				"completed":         true,
+				"final_distance_km": participant.FinalDistanceKm,
+			}).Error; err != nil {
+				return err
+			}
+
+			if err := RecordCredit(tx, &models.CreditLedgerEntry{
+				UserID:       participant.UserID,
+				RideID:       &ride.ID,
+				DistanceKm:   participant.FinalDistanceKm,
+				RideCount:    1,
+				Reason:       models.CreditReasonRideCompleted,
+				ActorID:      opts.ActorID,
+				CompletionID: &completion.ID,
+			}); err != nil {
+				return err
+			}
+
+			credited = append(credited, models.CreditedParticipant{
+				UserID:          participant.UserID,
+				FinalDistanceKm: participant.FinalDistanceKm,
+			})
+			completion.CreditedCount++
+			completion.TotalDistanceKm += participant.FinalDistanceKm
+		}
+
+		userIDs := make([]uuid.UUID, 0, len(credited)+1)
+		for _, c := range credited {
+			userIDs = append(userIDs, c.UserID)
+		}
+
+		if err := UpdateStreaks(tx, userIDs, now); err != nil {
+			return err
+		}
+
+		if ride.LeaderID != nil && !containsID(userIDs, *ride.LeaderID) {
+			userIDs = append(userIDs, *ride.LeaderID)
+		}
+
+		awarded, err := EvaluateBadges(tx, &ride, userIDs)
+		if err != nil {
+			return err
+		}
+		badges := make([]models.UserBadgeResponse, len(awarded))
+		for i := range awarded {
+			if err := tx.Model(&awarded[i]).Update("completion_id", completion.ID).Error; err != nil {
+				return err
+			}
+			badges[i] = awarded[i].ToResponse()
+		}
+
+		if opts.Report != nil {
+			if err := SaveRideReport(tx, ride.ID, opts.Report); err != nil {
+				return err
+			}
+		}
+
+		if err := tx.Model(&completion).Updates(map[string]interface{}{
+			"credited_count":    completion.CreditedCount,
+			"total_distance_km": completion.TotalDistanceKm,
+		}).Error; err != nil {
+			return err
+		}
+
+		summary = &models.RideCompletionSummary{
+			CompletionID:    completion.ID,
+			RideID:          ride.ID,
+			CompletedAt:     now,
+			CreditedCount:   completion.CreditedCount,
+			TotalDistanceKm: completion.TotalDistanceKm,
+			Credited:        credited,
+			Badges:          badges,
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return summary, nil
+}
+
+// replayedCompletion rebuilds the summary of an earlier completion from the
+// ledger entries and badges it recorded, so it shows what that completion
+// credited even if the ride was corrected or reopened since
+func replayedCompletion(db *gorm.DB, ride *models.Ride, completion *models.RideCompletion) (*models.RideCompletionSummary, error) {
+	var entries []models.CreditLedgerEntry
+	if err := db.Where("completion_id = ?", completion.ID).Order("created_at").Find(&entries).Error; err != nil {
+		return nil, err
+	}
+
+	credited := make([]models.CreditedParticipant, len(entries))
+	for i, entry := range entries {
+		credited[i] = models.CreditedParticipant{
+			UserID:          entry.UserID,
+			FinalDistanceKm: entry.DistanceKm,
+		}
+	}
+
+	var awarded []models.UserBadge
+	if err := db.Preload("Badge").Where("completion_id = ?", completion.ID).Find(&awarded).Error; err != nil {
+		return nil, err
+	}
+
+	badges := make([]models.UserBadgeResponse, len(awarded))
+	for i := range awarded {
+		badges[i] = awarded[i].ToResponse()
+	}
+
+	var later int64
+	if err := db.Model(&models.RideCompletion{}).
+		Where("ride_id = ? AND created_at > ?", completion.RideID, completion.CreatedAt).
+		Count(&later).Error; err != nil {
+		return nil, err
+	}
+
+	return &models.RideCompletionSummary{
+		CompletionID:    completion.ID,
+		RideID:          completion.RideID,
+		CompletedAt:     completion.CreatedAt,
+		CreditedCount:   completion.CreditedCount,
+		TotalDistanceKm: completion.TotalDistanceKm,
+		Credited:        credited,
+		Badges:          badges,
+		Replayed:        true,
+		Superseded:      ride.Status != models.RideStatusCompleted || later > 0,
+	}, nil
+}
+
+// SaveRideReport creates the ride's report or replaces the existing one,
+// keeping its ID and creation time
+func SaveRideReport(db *gorm.DB, rideID uuid.UUID, report *models.RideReport) error {
+	var existing models.RideReport
+	if err := db.Where("ride_id = ?", rideID).First(&existing).Error; err == nil {
+		report.ID = existing.ID
+		report.CreatedAt = existing.CreatedAt
+	}
+
+	report.RideID = rideID
+	return db.Omit(clause.Associations).Save(report).Error
+}
+
+// PurgeRidePositions deletes the live positions recorded during a ride,
+// keeping those of riders who opted to keep them.
+func PurgeRidePositions(db *gorm.DB, rideID uuid.UUID) error {
+	return db.
+		Where("ride_id = ? AND user_id NOT IN (?)", rideID,
+			db.Model(&models.RideParticipant{}).
+				Select("user_id").
+				Where("ride_id = ? AND keep_positions = ?", rideID, true)).
+		Delete(&models.RiderPosition{}).Error
+}
+
+// ReopenRide returns a completed ride to ongoing so attendance and bonuses
+// can be corrected before completing it again. Every credited participant's
+// distance and ride count are taken back off their totals, and each reversal
+// is written to the audit log. The ride row is locked like in CompleteRide
+// so a reopen can't race another reopen or a completion. It returns the
+// number of reversed credits.
+func ReopenRide(db *gorm.DB, rideID uuid.UUID, actorID uuid.UUID, reason string) (int, error) {
+	reversed := 0
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var ride models.Ride
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&ride, "id = ?", rideID).Error; err != nil {
+			return err
+		}
+		if ride.Status != models.RideStatusCompleted {
+			return ErrRideNotCompleted
+		}
+
+		var participants []models.RideParticipant
+		if err := tx.Where("ride_id = ? AND completed = ?", ride.ID, true).Find(&participants).Error; err != nil {
+			return err
+		}
+
+		for i := range participants {
+			participant := &participants[i]
+
+			if err := RecordCredit(tx, &models.CreditLedgerEntry{
+				UserID:     participant.UserID,
+				RideID:     &ride.ID,
+				DistanceKm: -participant.FinalDistanceKm,
+				RideCount:  -1,
+				Reason:     models.CreditReasonRideReopened,
+				Note:       reason,
+				ActorID:    &actorID,
+			}); err != nil {
+				return err
+			}
+
+			if err := tx.Create(&models.AuditLog{
+				Action:        models.AuditActionCreditReversed,
+				ActorID:       &actorID,
+				RideID:        &ride.ID,
+				SubjectUserID: &participant.UserID,
+				DistanceKm:    -participant.FinalDistanceKm,
+				RideCount:     -1,
+				Reason:        reason,
+			}).Error; err != nil {
+				return err
+			}
+
+			if err := tx.Model(participant).Updates(map[string]interface{}{
+				"completed":         false,
+				"final_distance_km": 0,
+			}).Error; err != nil {
+				return err
+			}
+			reversed++
+		}
+
+		if err := RevokeRideBadges(tx, ride.ID); err != nil {
+			return err
+		}
+
+		now := time.Now()
+		ride.Status = models.RideStatusOngoing
+		ride.CompletedAt = nil
+		ride.NeedsReview = false
+		ride.ReopenedAt = &now
+		if err := tx.Omit(clause.Associations).Save(&ride).Error; err != nil {
+			return err
+		}
+
+		userIDs := make([]uuid.UUID, len(participants))
+		for i, participant := range participants {
+			userIDs[i] = participant.UserID
+		}
+		if err := UpdateStreaks(tx, userIDs, now); err != nil {
+			return err
+		}
+
+		return tx.Create(&models.AuditLog{
+			Action:    models.AuditActionRideReopened,
+			ActorID:   &actorID,
+			RideID:    &ride.ID,
+			RideCount: reversed,
+			Reason:    reason,
+		}).Error
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return reversed, nil
+}
+
+func containsID(ids []uuid.UUID, id uuid.UUID) bool {
+	for _, existing := range ids {
+		if existing == id {
+			return true
+		}
+	}
+	return false
+}
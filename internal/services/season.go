@@ -0,0 +1,65 @@
+package services
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/udacc/uda-cycling-club/internal/config"
+	"github.com/udacc/uda-cycling-club/internal/models"
+)
+
+// RideDate is the SQL expression for when a ride was ridden, as opposed to
+// completed_at, which is when it was closed and moves when a ride is
+// reopened. Seasons, periods and streaks are all bucketed by it.
+const RideDate = "COALESCE(rides.start_time, rides.started_at, rides.completed_at)"
+
+// rideDateOf is RideDate for a loaded ride, falling back to now
+func rideDateOf(ride *models.Ride) time.Time {
+	switch {
+	case ride.StartTime != nil:
+		return *ride.StartTime
+	case ride.StartedAt != nil:
+		return *ride.StartedAt
+	case ride.CompletedAt != nil:
+		return *ride.CompletedAt
+	}
+	return time.Now()
+}
+
+// ClubLocation is the time zone ride times are shown and grouped in
+func ClubLocation() *time.Location {
+	loc, err := time.LoadLocation("Asia/Ulaanbaatar")
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+// SeasonRange is the [from, to) riding season containing t, or the last one
+// if that year's season hasn't started yet. A season may run over New Year.
+func SeasonRange(t time.Time) (time.Time, time.Time) {
+	t = t.In(ClubLocation())
+	startMonth := time.Month(config.AppConfig.SeasonStartMonth)
+	endMonth := time.Month(config.AppConfig.SeasonEndMonth)
+
+	from := time.Date(t.Year(), startMonth, 1, 0, 0, 0, 0, t.Location())
+	if t.Before(from) {
+		from = from.AddDate(-1, 0, 0)
+	}
+
+	to := time.Date(from.Year(), endMonth+1, 1, 0, 0, 0, 0, t.Location())
+	if endMonth < startMonth {
+		to = to.AddDate(1, 0, 0)
+	}
+	return from, to
+}
+
+// SeasonLabel names the season starting at from: "2026", or "2026-2027"
+// for a season running over New Year
+func SeasonLabel(from, to time.Time) string {
+	label := strconv.Itoa(from.Year())
+	if last := to.Add(-time.Nanosecond); last.Year() != from.Year() {
+		label += "-" + strconv.Itoa(last.Year())
+	}
+	return label
+}
@@ -0,0 +1,102 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/udacc/uda-cycling-club/internal/config"
+)
+
+func TestSeasonRange(t *testing.T) {
+	previous := config.AppConfig
+	t.Cleanup(func() { config.AppConfig = previous })
+
+	loc := ClubLocation()
+	day := func(year int, month time.Month, d int) time.Time {
+		return time.Date(year, month, d, 0, 0, 0, 0, loc)
+	}
+
+	tests := []struct {
+		name       string
+		startMonth int
+		endMonth   int
+		t          time.Time
+		wantFrom   time.Time
+		wantTo     time.Time
+		wantLabel  string
+	}{
+		{
+			name:       "during the season",
+			startMonth: 4, endMonth: 10,
+			t:        day(2026, time.July, 15),
+			wantFrom: day(2026, time.April, 1), wantTo: day(2026, time.November, 1),
+			wantLabel: "2026",
+		},
+		{
+			name:       "first moment of the season",
+			startMonth: 4, endMonth: 10,
+			t:        day(2026, time.April, 1),
+			wantFrom: day(2026, time.April, 1), wantTo: day(2026, time.November, 1),
+			wantLabel: "2026",
+		},
+		{
+			name:       "after the season ends",
+			startMonth: 4, endMonth: 10,
+			t:        day(2026, time.December, 10),
+			wantFrom: day(2026, time.April, 1), wantTo: day(2026, time.November, 1),
+			wantLabel: "2026",
+		},
+		{
+			name:       "before the season starts uses last year's",
+			startMonth: 4, endMonth: 10,
+			t:        day(2026, time.March, 31),
+			wantFrom: day(2025, time.April, 1), wantTo: day(2025, time.November, 1),
+			wantLabel: "2025",
+		},
+		{
+			name:       "UTC time already in the season in club time",
+			startMonth: 4, endMonth: 10,
+			t:        day(2026, time.April, 1).Add(time.Minute).UTC(),
+			wantFrom: day(2026, time.April, 1), wantTo: day(2026, time.November, 1),
+			wantLabel: "2026",
+		},
+		{
+			name:       "whole year",
+			startMonth: 1, endMonth: 12,
+			t:        day(2026, time.June, 1),
+			wantFrom: day(2026, time.January, 1), wantTo: day(2027, time.January, 1),
+			wantLabel: "2026",
+		},
+		{
+			name:       "winter season before New Year",
+			startMonth: 11, endMonth: 3,
+			t:        day(2026, time.December, 20),
+			wantFrom: day(2026, time.November, 1), wantTo: day(2027, time.April, 1),
+			wantLabel: "2026-2027",
+		},
+		{
+			name:       "winter season after New Year",
+			startMonth: 11, endMonth: 3,
+			t:        day(2027, time.February, 5),
+			wantFrom: day(2026, time.November, 1), wantTo: day(2027, time.April, 1),
+			wantLabel: "2026-2027",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config.AppConfig = &config.Config{
+				SeasonStartMonth: tt.startMonth,
+				SeasonEndMonth:   tt.endMonth,
+			}
+
+			from, to := SeasonRange(tt.t)
+			if !from.Equal(tt.wantFrom) || !to.Equal(tt.wantTo) {
+				t.Errorf("SeasonRange(%v) = [%v, %v), want [%v, %v)", tt.t, from, to, tt.wantFrom, tt.wantTo)
+			}
+			if label := SeasonLabel(from, to); label != tt.wantLabel {
+				t.Errorf("SeasonLabel(%v, %v) = %q, want %q", from, to, label, tt.wantLabel)
+			}
+		})
+	}
+}
@@ -0,0 +1,70 @@
+package services
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/udacc/uda-cycling-club/internal/models"
+	"github.com/udacc/uda-cycling-club/pkg/streak"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// UpdateStreaks recomputes the weekly and monthly streaks of the given
+// users from their completed rides, in club time. Rides count in the period
+// they were ridden, not the one they were marked completed in.
+func UpdateStreaks(db *gorm.DB, userIDs []uuid.UUID, now time.Time) error {
+	loc := ClubLocation()
+
+	for _, userID := range userIDs {
+		var riddenAt []time.Time
+		if err := db.Table("ride_participants").
+			Joins("JOIN rides ON rides.id = ride_participants.ride_id").
+			Where("ride_participants.user_id = ? AND ride_participants.completed = ? AND rides.status = ?",
+				userID, true, models.RideStatusCompleted).
+			Pluck(RideDate, &riddenAt).Error; err != nil {
+			return err
+		}
+
+		for _, period := range streak.Periods {
+			result := streak.Compute(riddenAt, period, now, loc)
+
+			row := models.UserStreak{
+				UserID:  userID,
+				Period:  string(period),
+				Current: result.Current,
+				Longest: result.Longest,
+			}
+			if !result.LastPeriod.IsZero() {
+				row.LastPeriod = &result.LastPeriod
+				row.ExpiresAt = &result.ExpiresAt
+			}
+
+			if err := db.Clauses(clause.OnConflict{
+				Columns:   []clause.Column{{Name: "user_id"}, {Name: "period"}},
+				DoUpdates: clause.AssignmentColumns([]string{"current", "longest", "last_period", "expires_at", "updated_at"}),
+			}).Create(&row).Error; err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// RecomputeStreaks rebuilds the streaks of every user. It returns the
+// number of users processed.
+func RecomputeStreaks(db *gorm.DB, now time.Time) (int, error) {
+	var userIDs []uuid.UUID
+	if err := db.Model(&models.User{}).Pluck("id", &userIDs).Error; err != nil {
+		return 0, err
+	}
+
+	if err := db.Transaction(func(tx *gorm.DB) error {
+		return UpdateStreaks(tx, userIDs, now)
+	}); err != nil {
+		return 0, err
+	}
+
+	return len(userIDs), nil
+}
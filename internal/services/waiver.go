@@ -0,0 +1,63 @@
+package services
+
+import (
+	"github.com/google/uuid"
+	"github.com/udacc/uda-cycling-club/internal/models"
+	"gorm.io/gorm"
+)
+
+// CurrentWaiverVersion returns the latest published version of a waiver, or
+// nil if none has been published.
+func CurrentWaiverVersion(db *gorm.DB, waiverID uint) *models.WaiverVersion {
+	var version models.WaiverVersion
+	err := db.
+		Where("waiver_id = ? AND published_at IS NOT NULL", waiverID).
+		Order("version DESC").
+		First(&version).Error
+	if err != nil {
+		return nil
+	}
+	return &version
+}
+
+// RequiredWaivers returns the active waivers a rider must accept to join a
+// ride of the given type: every general waiver plus those the type requires.
+func RequiredWaivers(db *gorm.DB, rideTypeID uint) []models.Waiver {
+	var waivers []models.Waiver
+	db.
+		Where("is_active = ?", true).
+		Where("is_general = ? OR id IN (?)", true,
+			db.Model(&models.RideTypeWaiver{}).Select("waiver_id").Where("ride_type_id = ?", rideTypeID)).
+		Order("id").
+		Find(&waivers)
+	return waivers
+}
+
+// PendingWaiver is a required waiver whose current version the user has
+// not accepted.
+type PendingWaiver struct {
+	Waiver  models.Waiver
+	Current models.WaiverVersion
+}
+
+// PendingWaivers lists the waivers the user must still accept before
+// registering for a ride of the given type. Waivers that were never
+// published are not enforced.
+func PendingWaivers(db *gorm.DB, userID uuid.UUID, rideTypeID uint) []PendingWaiver {
+	var pending []PendingWaiver
+	for _, waiver := range RequiredWaivers(db, rideTypeID) {
+		current := CurrentWaiverVersion(db, waiver.ID)
+		if current == nil {
+			continue
+		}
+
+		var count int64
+		db.Model(&models.WaiverAcceptance{}).
+			Where("user_id = ? AND waiver_version_id = ?", userID, current.ID).
+			Count(&count)
+		if count == 0 {
+			pending = append(pending, PendingWaiver{Waiver: waiver, Current: *current})
+		}
+	}
+	return pending
+}
@@ -0,0 +1,73 @@
+package gpx
+
+import (
+	"errors"
+	"math"
+)
+
+// DifficultyWeights controls how much each route characteristic adds to the
+// difficulty score, and the score at which each level from 2 to 5 begins.
+type DifficultyWeights struct {
+	DistancePerKm      float64 `json:"distance_per_km"`
+	ElevationPer100m   float64 `json:"elevation_per_100m"`
+	GradientPerPercent float64 `json:"gradient_per_percent"`
+	PerPass            float64 `json:"per_pass"`
+	Level2Score        float64 `json:"level_2_score"`
+	Level3Score        float64 `json:"level_3_score"`
+	Level4Score        float64 `json:"level_4_score"`
+	Level5Score        float64 `json:"level_5_score"`
+}
+
+// DefaultDifficultyWeights rate 100 km of flat road about the same as 60 km
+// with 1000 m of climbing.
+var DefaultDifficultyWeights = DifficultyWeights{
+	DistancePerKm:      0.5,
+	ElevationPer100m:   2.0,
+	GradientPerPercent: 0.5,
+	PerPass:            3.0,
+	Level2Score:        20,
+	Level3Score:        40,
+	Level4Score:        70,
+	Level5Score:        110,
+}
+
+const (
+	minDifficultyLevel = 1
+	maxDifficultyLevel = 5
+	// GPX gradients are noisy on short segments, so cap their influence
+	maxScoredGradient = 25.0
+)
+
+// CalculateDifficulty returns a difficulty score and a level from 1 to 5.
+func CalculateDifficulty(distanceKm, elevationGain, maxGradient float64, passCount int, w DifficultyWeights) (float64, int) {
+	gradient := math.Min(math.Max(maxGradient, 0), maxScoredGradient)
+
+	score := distanceKm*w.DistancePerKm +
+		elevationGain/100*w.ElevationPer100m +
+		gradient*w.GradientPerPercent +
+		float64(passCount)*w.PerPass
+	score = math.Round(score*10) / 10
+
+	level := minDifficultyLevel
+	for _, threshold := range []float64{w.Level2Score, w.Level3Score, w.Level4Score, w.Level5Score} {
+		if score >= threshold {
+			level++
+		}
+	}
+	if level > maxDifficultyLevel {
+		level = maxDifficultyLevel
+	}
+
+	return score, level
+}
+
+// Validate checks that weights are non-negative and level thresholds ascend.
+func (w DifficultyWeights) Validate() error {
+	if w.DistancePerKm < 0 || w.ElevationPer100m < 0 || w.GradientPerPercent < 0 || w.PerPass < 0 {
+		return errors.New("weights cannot be negative")
+	}
+	if !(w.Level2Score < w.Level3Score && w.Level3Score < w.Level4Score && w.Level4Score < w.Level5Score) {
+		return errors.New("level scores must be in ascending order")
+	}
+	return nil
+}
@@ -0,0 +1,45 @@
+package gpx
+
+import "testing"
+
+func TestCalculateDifficulty(t *testing.T) {
+	steep := DifficultyWeights{
+		DistancePerKm: 1,
+		Level2Score:   1,
+		Level3Score:   2,
+		Level4Score:   3,
+		Level5Score:   4,
+	}
+
+	tests := []struct {
+		name          string
+		distanceKm    float64
+		elevationGain float64
+		maxGradient   float64
+		passCount     int
+		weights       DifficultyWeights
+		wantScore     float64
+		wantLevel     int
+	}{
+		{name: "no route", weights: DefaultDifficultyWeights, wantScore: 0, wantLevel: 1},
+		{name: "short flat ride", distanceKm: 30, weights: DefaultDifficultyWeights, wantScore: 15, wantLevel: 1},
+		{name: "level threshold is inclusive", distanceKm: 40, weights: DefaultDifficultyWeights, wantScore: 20, wantLevel: 2},
+		{name: "100 km flat", distanceKm: 100, weights: DefaultDifficultyWeights, wantScore: 50, wantLevel: 3},
+		{name: "60 km with 1000 m climbing", distanceKm: 60, elevationGain: 1000, weights: DefaultDifficultyWeights, wantScore: 50, wantLevel: 3},
+		{name: "gradient is capped", maxGradient: 40, weights: DefaultDifficultyWeights, wantScore: 12.5, wantLevel: 1},
+		{name: "negative gradient ignored", distanceKm: 10, maxGradient: -8, weights: DefaultDifficultyWeights, wantScore: 5, wantLevel: 1},
+		{name: "passes", distanceKm: 100, elevationGain: 1500, maxGradient: 12, passCount: 3, weights: DefaultDifficultyWeights, wantScore: 95, wantLevel: 4},
+		{name: "hardest level", distanceKm: 200, elevationGain: 2500, maxGradient: 15, passCount: 5, weights: DefaultDifficultyWeights, wantScore: 172.5, wantLevel: 5},
+		{name: "score rounded to one decimal", distanceKm: 12.34, weights: DefaultDifficultyWeights, wantScore: 6.2, wantLevel: 1},
+		{name: "level capped at five", distanceKm: 10, weights: steep, wantScore: 10, wantLevel: 5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			score, level := CalculateDifficulty(tt.distanceKm, tt.elevationGain, tt.maxGradient, tt.passCount, tt.weights)
+			if score != tt.wantScore || level != tt.wantLevel {
+				t.Errorf("CalculateDifficulty() = (%v, %d), want (%v, %d)", score, level, tt.wantScore, tt.wantLevel)
+			}
+		})
+	}
+}
@@ -26,6 +26,8 @@ type RouteStats struct {
 	MaxDescent    float64    `json:"max_descent"`
 	PassCount     int        `json:"pass_count"`
 	Passes        []PassInfo `json:"passes,omitempty"`
+	DifficultyScore float64  `json:"difficulty_score,omitempty"`
+	DifficultyLevel int      `json:"difficulty_level,omitempty"`
 }
 
 type RoutePoint struct {
@@ -246,7 +248,7 @@ func ParseGPXFile(filepath string) (*RouteStats, error) {
 		prevPoint := points[i-1]
 		currPoint := points[i]
 
-		distance := haversineDistance(
+		distance := HaversineDistance(
 			prevPoint.Latitude, prevPoint.Longitude,
 			currPoint.Latitude, currPoint.Longitude,
 		)
@@ -307,7 +309,8 @@ func ParseGPXFile(filepath string) (*RouteStats, error) {
 	return stats, nil
 }
 
-func haversineDistance(lat1, lon1, lat2, lon2 float64) float64 {
+// HaversineDistance returns the great-circle distance in kilometers
+func HaversineDistance(lat1, lon1, lat2, lon2 float64) float64 {
 	lat1Rad := lat1 * math.Pi / 180
 	lat2Rad := lat2 * math.Pi / 180
 	deltaLat := (lat2 - lat1) * math.Pi / 180
@@ -0,0 +1,46 @@
+package gpx
+
+import "math"
+
+// RouteProgress projects positions onto a route to tell how far along it a
+// rider is.
+type RouteProgress struct {
+	points     []RoutePoint
+	cumulative []float64
+}
+
+func NewRouteProgress(points []RoutePoint) *RouteProgress {
+	cumulative := make([]float64, len(points))
+	for i := 1; i < len(points); i++ {
+		cumulative[i] = cumulative[i-1] + HaversineDistance(
+			points[i-1].Lat, points[i-1].Lng,
+			points[i].Lat, points[i].Lng,
+		)
+	}
+
+	return &RouteProgress{
+		points:     points,
+		cumulative: cumulative,
+	}
+}
+
+// DistanceAlong returns the distance in km from the route start to the
+// route point nearest to the position, and how far in km the position is
+// from that point. Both are zero for an empty route.
+func (r *RouteProgress) DistanceAlong(lat, lng float64) (alongKm, offRouteKm float64) {
+	if len(r.points) == 0 {
+		return 0, 0
+	}
+
+	nearest := 0
+	offRouteKm = math.MaxFloat64
+	for i, p := range r.points {
+		d := HaversineDistance(lat, lng, p.Lat, p.Lng)
+		if d < offRouteKm {
+			offRouteKm = d
+			nearest = i
+		}
+	}
+
+	return math.Round(r.cumulative[nearest]*100) / 100, math.Round(offRouteKm*100) / 100
+}
@@ -0,0 +1,158 @@
+package ical
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	timeFormat    = "20060102T150405Z"
+	maxLineOctets = 75
+)
+
+type EventStatus string
+
+const (
+	StatusConfirmed EventStatus = "CONFIRMED"
+	StatusTentative EventStatus = "TENTATIVE"
+	StatusCancelled EventStatus = "CANCELLED"
+)
+
+// Event is a single VEVENT. UID must stay the same for the life of the event
+// and Sequence must grow whenever its time, place or status changes, so
+// calendar clients update the existing entry instead of adding a new one.
+type Event struct {
+	UID          string
+	Sequence     int
+	Created      time.Time
+	LastModified time.Time
+	Start        time.Time
+	End          *time.Time
+	Summary      string
+	Description  string
+	Location     string
+	Lat          *float64
+	Lng          *float64
+	URL          string
+	Status       EventStatus
+}
+
+// Calendar is a VCALENDAR document.
+type Calendar struct {
+	ProductID string
+	Name      string
+	Events    []Event
+}
+
+// Write encodes the calendar as RFC 5545 text.
+func (c *Calendar) Write(w io.Writer) error {
+	lw := &lineWriter{w: w}
+
+	lw.line("BEGIN:VCALENDAR")
+	lw.line("VERSION:2.0")
+	lw.line("PRODID:" + c.ProductID)
+	lw.line("CALSCALE:GREGORIAN")
+	lw.line("METHOD:PUBLISH")
+	if c.Name != "" {
+		lw.line("X-WR-CALNAME:" + escapeText(c.Name))
+	}
+
+	now := time.Now()
+	for _, e := range c.Events {
+		lw.line("BEGIN:VEVENT")
+		lw.line("UID:" + e.UID)
+		lw.line(fmt.Sprintf("SEQUENCE:%d", e.Sequence))
+		lw.line("DTSTAMP:" + formatTime(now))
+		if !e.Created.IsZero() {
+			lw.line("CREATED:" + formatTime(e.Created))
+		}
+		if !e.LastModified.IsZero() {
+			lw.line("LAST-MODIFIED:" + formatTime(e.LastModified))
+		}
+		lw.line("DTSTART:" + formatTime(e.Start))
+		if e.End != nil {
+			lw.line("DTEND:" + formatTime(*e.End))
+		}
+		lw.line("SUMMARY:" + escapeText(e.Summary))
+		if e.Description != "" {
+			lw.line("DESCRIPTION:" + escapeText(e.Description))
+		}
+		if e.Location != "" {
+			lw.line("LOCATION:" + escapeText(e.Location))
+		}
+		if e.Lat != nil && e.Lng != nil {
+			lw.line(fmt.Sprintf("GEO:%.6f;%.6f", *e.Lat, *e.Lng))
+		}
+		if e.URL != "" {
+			lw.line("URL:" + e.URL)
+		}
+		if e.Status != "" {
+			lw.line("STATUS:" + string(e.Status))
+		}
+		lw.line("END:VEVENT")
+	}
+
+	lw.line("END:VCALENDAR")
+	return lw.err
+}
+
+// Bytes encodes the calendar and returns the result.
+func (c *Calendar) Bytes() ([]byte, error) {
+	var buf bytes.Buffer
+	if err := c.Write(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func formatTime(t time.Time) string {
+	return t.UTC().Format(timeFormat)
+}
+
+var textEscaper = strings.NewReplacer(
+	`\`, `\\`,
+	";", `\;`,
+	",", `\,`,
+	"\r\n", `\n`,
+	"\n", `\n`,
+	"\r", `\n`,
+)
+
+func escapeText(s string) string {
+	return textEscaper.Replace(s)
+}
+
+// lineWriter writes CRLF-terminated content lines, folding those longer
+// than 75 octets without splitting UTF-8 characters.
+type lineWriter struct {
+	w   io.Writer
+	err error
+}
+
+func (lw *lineWriter) line(s string) {
+	if lw.err != nil {
+		return
+	}
+
+	var buf strings.Builder
+	limit := maxLineOctets
+	for len(s) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(s[cut]) {
+			cut--
+		}
+		buf.WriteString(s[:cut])
+		buf.WriteString("\r\n ")
+		s = s[cut:]
+		// Continuation lines start with a space, which counts toward the limit
+		limit = maxLineOctets - 1
+	}
+	buf.WriteString(s)
+	buf.WriteString("\r\n")
+
+	_, lw.err = io.WriteString(lw.w, buf.String())
+}
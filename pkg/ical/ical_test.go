@@ -0,0 +1,103 @@
+package ical
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestEscapeText(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{name: "plain", in: "Morning ride", want: "Morning ride"},
+		{name: "comma and semicolon", in: "Zaisan, Bogd Khan; loop", want: `Zaisan\, Bogd Khan\; loop`},
+		{name: "backslash", in: `C:\rides`, want: `C:\\rides`},
+		{name: "backslash before comma", in: `a\,b`, want: `a\\\,b`},
+		{name: "newlines", in: "line one\nline two\r\nline three\rend", want: `line one\nline two\nline three\nend`},
+		{name: "cyrillic untouched", in: "Өдрийн аялал", want: "Өдрийн аялал"},
+		{name: "empty", in: "", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := escapeText(tt.in); got != tt.want {
+				t.Errorf("escapeText(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLineFolding(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{
+			name: "short line",
+			in:   "SUMMARY:Ride",
+			want: "SUMMARY:Ride\r\n",
+		},
+		{
+			name: "exactly 75 octets",
+			in:   strings.Repeat("a", 75),
+			want: strings.Repeat("a", 75) + "\r\n",
+		},
+		{
+			name: "76 octets",
+			in:   strings.Repeat("a", 76),
+			want: strings.Repeat("a", 75) + "\r\n a\r\n",
+		},
+		{
+			name: "continuation lines hold 74 octets",
+			in:   strings.Repeat("a", 75+74+1),
+			want: strings.Repeat("a", 75) + "\r\n " + strings.Repeat("a", 74) + "\r\n a\r\n",
+		},
+		{
+			name: "multibyte character is not split",
+			// 74 ASCII octets then a two-octet character crossing the limit
+			in:   strings.Repeat("a", 74) + "Ө" + "b",
+			want: strings.Repeat("a", 74) + "\r\n Өb\r\n",
+		},
+		{
+			name: "long cyrillic text",
+			in:   "DESCRIPTION:" + strings.Repeat("Хан-Уул ", 20),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf strings.Builder
+			lw := &lineWriter{w: &buf}
+			lw.line(tt.in)
+			if lw.err != nil {
+				t.Fatalf("line() error = %v", lw.err)
+			}
+			got := buf.String()
+
+			if tt.want != "" && got != tt.want {
+				t.Errorf("line(%q) wrote %q, want %q", tt.in, got, tt.want)
+			}
+
+			if !strings.HasSuffix(got, "\r\n") {
+				t.Fatalf("output %q does not end with CRLF", got)
+			}
+			for _, physical := range strings.Split(strings.TrimSuffix(got, "\r\n"), "\r\n") {
+				if len(physical) > maxLineOctets {
+					t.Errorf("line %q is %d octets, longer than %d", physical, len(physical), maxLineOctets)
+				}
+				if !utf8.ValidString(physical) {
+					t.Errorf("line %q splits a UTF-8 character", physical)
+				}
+			}
+
+			unfolded := strings.ReplaceAll(strings.TrimSuffix(got, "\r\n"), "\r\n ", "")
+			if unfolded != tt.in {
+				t.Errorf("unfolded output = %q, want %q", unfolded, tt.in)
+			}
+		})
+	}
+}
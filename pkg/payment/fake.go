@@ -0,0 +1,80 @@
+package payment
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// FakeProvider is an in-memory provider for development. Every charge
+// succeeds immediately; charges are lost on restart.
+type FakeProvider struct {
+	mu      sync.Mutex
+	charges map[string]*Charge
+	nextID  int
+}
+
+func NewFakeProvider() *FakeProvider {
+	return &FakeProvider{
+		charges: make(map[string]*Charge),
+	}
+}
+
+func (p *FakeProvider) Name() string {
+	return "fake"
+}
+
+func (p *FakeProvider) CreateCharge(ctx context.Context, req ChargeRequest) (*Charge, error) {
+	if req.Amount <= 0 {
+		return nil, fmt.Errorf("invalid charge amount: %v", req.Amount)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.nextID++
+	charge := &Charge{
+		ID:        fmt.Sprintf("fake_%d", p.nextID),
+		Status:    ChargeStatusSucceeded,
+		Amount:    req.Amount,
+		Currency:  req.Currency,
+		Reference: req.Reference,
+		CreatedAt: time.Now(),
+	}
+	p.charges[charge.ID] = charge
+
+	result := *charge
+	return &result, nil
+}
+
+func (p *FakeProvider) GetCharge(ctx context.Context, id string) (*Charge, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	charge, ok := p.charges[id]
+	if !ok {
+		return nil, ErrChargeNotFound
+	}
+
+	result := *charge
+	return &result, nil
+}
+
+func (p *FakeProvider) Refund(ctx context.Context, id string) (*Charge, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	charge, ok := p.charges[id]
+	if !ok {
+		return nil, ErrChargeNotFound
+	}
+	if charge.Status != ChargeStatusSucceeded {
+		return nil, ErrNotRefundable
+	}
+
+	charge.Status = ChargeStatusRefunded
+
+	result := *charge
+	return &result, nil
+}
@@ -0,0 +1,62 @@
+package payment
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+var (
+	ErrUnknownProvider = errors.New("unknown payment provider")
+	ErrChargeNotFound  = errors.New("charge not found")
+	ErrNotRefundable   = errors.New("charge cannot be refunded")
+)
+
+type ChargeStatus string
+
+const (
+	ChargeStatusPending   ChargeStatus = "pending"
+	ChargeStatusSucceeded ChargeStatus = "succeeded"
+	ChargeStatusFailed    ChargeStatus = "failed"
+	ChargeStatusRefunded  ChargeStatus = "refunded"
+)
+
+// ChargeRequest describes a payment to collect from a rider.
+type ChargeRequest struct {
+	Amount      float64
+	Currency    string
+	Description string
+	// Reference ties the charge back to the registration it pays for
+	Reference string
+}
+
+// Charge is a payment as the provider sees it.
+type Charge struct {
+	ID        string
+	Status    ChargeStatus
+	Amount    float64
+	Currency  string
+	Reference string
+	// PaymentURL is where the rider completes the payment, if the provider
+	// needs them to leave the app
+	PaymentURL string
+	CreatedAt  time.Time
+}
+
+// Provider collects payments. Implementations must be safe for concurrent use.
+type Provider interface {
+	Name() string
+	CreateCharge(ctx context.Context, req ChargeRequest) (*Charge, error)
+	GetCharge(ctx context.Context, id string) (*Charge, error)
+	Refund(ctx context.Context, id string) (*Charge, error)
+}
+
+// NewProvider returns the provider with the given name.
+func NewProvider(name string) (Provider, error) {
+	switch name {
+	case "", "fake":
+		return NewFakeProvider(), nil
+	}
+	return nil, fmt.Errorf("%w: %s", ErrUnknownProvider, name)
+}
@@ -0,0 +1,89 @@
+package streak
+
+import (
+	"sort"
+	"time"
+)
+
+// Period is the length of one streak step.
+type Period string
+
+const (
+	Weekly  Period = "weekly"
+	Monthly Period = "monthly"
+)
+
+var Periods = []Period{Weekly, Monthly}
+
+func (p Period) IsValid() bool {
+	return p == Weekly || p == Monthly
+}
+
+// Start returns the start of the period containing t in loc. Weeks start on
+// Monday.
+func Start(t time.Time, p Period, loc *time.Location) time.Time {
+	t = t.In(loc)
+	if p == Monthly {
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, loc)
+	}
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc)
+	return day.AddDate(0, 0, -((int(day.Weekday()) + 6) % 7))
+}
+
+// Next returns the start of the period after the one starting at start.
+func Next(start time.Time, p Period) time.Time {
+	if p == Monthly {
+		return start.AddDate(0, 1, 0)
+	}
+	return start.AddDate(0, 0, 7)
+}
+
+// Result is a member's streak for one period length. LastPeriod is the
+// start of the latest period with activity. The current streak survives
+// until ExpiresAt, the end of the period after LastPeriod, so a streak
+// isn't broken before the member has had the whole period to ride.
+type Result struct {
+	Current    int
+	Longest    int
+	LastPeriod time.Time
+	ExpiresAt  time.Time
+}
+
+// Compute works out the streaks from activity times, which may be in any
+// order and may repeat within a period.
+func Compute(times []time.Time, p Period, now time.Time, loc *time.Location) Result {
+	if len(times) == 0 {
+		return Result{}
+	}
+
+	seen := make(map[time.Time]bool, len(times))
+	starts := make([]time.Time, 0, len(times))
+	for _, t := range times {
+		start := Start(t, p, loc)
+		if !seen[start] {
+			seen[start] = true
+			starts = append(starts, start)
+		}
+	}
+	sort.Slice(starts, func(i, j int) bool { return starts[i].Before(starts[j]) })
+
+	var result Result
+	run := 0
+	for i, start := range starts {
+		if i > 0 && Next(starts[i-1], p).Equal(start) {
+			run++
+		} else {
+			run = 1
+		}
+		if run > result.Longest {
+			result.Longest = run
+		}
+	}
+
+	result.LastPeriod = starts[len(starts)-1]
+	result.ExpiresAt = Next(Next(result.LastPeriod, p), p)
+	if now.Before(result.ExpiresAt) {
+		result.Current = run
+	}
+	return result
+}
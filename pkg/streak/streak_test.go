@@ -0,0 +1,163 @@
+package streak
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCompute(t *testing.T) {
+	loc := time.FixedZone("UTC+8", 8*60*60)
+	at := func(year int, month time.Month, day, hour int) time.Time {
+		return time.Date(year, month, day, hour, 0, 0, 0, loc)
+	}
+	day := func(year int, month time.Month, d int) time.Time {
+		return at(year, month, d, 0)
+	}
+
+	tests := []struct {
+		name   string
+		times  []time.Time
+		period Period
+		now    time.Time
+		want   Result
+	}{
+		{
+			name:   "no activity",
+			period: Weekly,
+			now:    day(2026, time.June, 3),
+			want:   Result{},
+		},
+		{
+			name:   "single week",
+			times:  []time.Time{at(2026, time.June, 3, 18)},
+			period: Weekly,
+			now:    day(2026, time.June, 4),
+			want: Result{
+				Current:    1,
+				Longest:    1,
+				LastPeriod: day(2026, time.June, 1),
+				ExpiresAt:  day(2026, time.June, 15),
+			},
+		},
+		{
+			name: "consecutive weeks in any order with repeats",
+			times: []time.Time{
+				at(2026, time.June, 15, 9),
+				at(2026, time.June, 1, 9),
+				at(2026, time.June, 10, 9),
+				at(2026, time.June, 7, 9),
+			},
+			period: Weekly,
+			now:    day(2026, time.June, 16),
+			want: Result{
+				Current:    3,
+				Longest:    3,
+				LastPeriod: day(2026, time.June, 15),
+				ExpiresAt:  day(2026, time.June, 29),
+			},
+		},
+		{
+			name: "gap resets the current run",
+			times: []time.Time{
+				at(2026, time.May, 18, 9),
+				at(2026, time.May, 25, 9),
+				at(2026, time.June, 8, 9),
+			},
+			period: Weekly,
+			now:    day(2026, time.June, 9),
+			want: Result{
+				Current:    1,
+				Longest:    2,
+				LastPeriod: day(2026, time.June, 8),
+				ExpiresAt:  day(2026, time.June, 22),
+			},
+		},
+		{
+			name: "current streak survives the following week",
+			times: []time.Time{
+				at(2026, time.June, 1, 9),
+				at(2026, time.June, 8, 9),
+			},
+			period: Weekly,
+			now:    at(2026, time.June, 21, 23),
+			want: Result{
+				Current:    2,
+				Longest:    2,
+				LastPeriod: day(2026, time.June, 8),
+				ExpiresAt:  day(2026, time.June, 22),
+			},
+		},
+		{
+			name: "expired streak keeps the longest",
+			times: []time.Time{
+				at(2026, time.June, 1, 9),
+				at(2026, time.June, 8, 9),
+			},
+			period: Weekly,
+			now:    day(2026, time.June, 22),
+			want: Result{
+				Current:    0,
+				Longest:    2,
+				LastPeriod: day(2026, time.June, 8),
+				ExpiresAt:  day(2026, time.June, 22),
+			},
+		},
+		{
+			name: "weeks are bucketed in the given location",
+			times: []time.Time{
+				at(2026, time.May, 27, 9),
+				// Sunday evening in UTC is already Monday in loc
+				time.Date(2026, time.May, 31, 17, 0, 0, 0, time.UTC),
+			},
+			period: Weekly,
+			now:    day(2026, time.June, 2),
+			want: Result{
+				Current:    2,
+				Longest:    2,
+				LastPeriod: day(2026, time.June, 1),
+				ExpiresAt:  day(2026, time.June, 15),
+			},
+		},
+		{
+			name: "consecutive months over New Year",
+			times: []time.Time{
+				at(2025, time.November, 30, 9),
+				at(2025, time.December, 1, 9),
+				at(2026, time.January, 31, 9),
+			},
+			period: Monthly,
+			now:    day(2026, time.February, 10),
+			want: Result{
+				Current:    3,
+				Longest:    3,
+				LastPeriod: day(2026, time.January, 1),
+				ExpiresAt:  day(2026, time.March, 1),
+			},
+		},
+		{
+			name: "skipped month",
+			times: []time.Time{
+				at(2026, time.April, 5, 9),
+				at(2026, time.June, 5, 9),
+			},
+			period: Monthly,
+			now:    day(2026, time.August, 1),
+			want: Result{
+				Current:    0,
+				Longest:    1,
+				LastPeriod: day(2026, time.June, 1),
+				ExpiresAt:  day(2026, time.August, 1),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Compute(tt.times, tt.period, tt.now, loc)
+			if got.Current != tt.want.Current || got.Longest != tt.want.Longest ||
+				!got.LastPeriod.Equal(tt.want.LastPeriod) || !got.ExpiresAt.Equal(tt.want.ExpiresAt) {
+				t.Errorf("Compute() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}